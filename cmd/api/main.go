@@ -1,67 +1,222 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/joho/godotenv"
 
 	chiMiddleware "github.com/go-chi/chi/v5/middleware"
 	middleware "github.com/rgdevment/spam-registry/internal/platform/http/middleware"
 
+	"github.com/rgdevment/spam-registry/internal/platform/blocklist"
+	"github.com/rgdevment/spam-registry/internal/platform/config"
+	"github.com/rgdevment/spam-registry/internal/platform/crypto/envelope"
+	"github.com/rgdevment/spam-registry/internal/platform/health"
 	httpHandler "github.com/rgdevment/spam-registry/internal/platform/http"
-	"github.com/rgdevment/spam-registry/internal/platform/storage/scylla"
+	"github.com/rgdevment/spam-registry/internal/platform/logging"
+	"github.com/rgdevment/spam-registry/internal/platform/metrics"
+	"github.com/rgdevment/spam-registry/internal/platform/spool"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/backend"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/cache"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/encrypted"
+	"github.com/rgdevment/spam-registry/internal/platform/tracing"
 	"github.com/rgdevment/spam-registry/internal/service"
 )
 
 func main() {
-	if err := godotenv.Load(); err != nil {
-		log.Println("⚠️  No .env file found, using system environment variables")
+	cfg, err := config.Load(config.API, flag.CommandLine, os.Args[1:])
+	if err != nil {
+		fatal("❌ Configuración inválida", logging.Err(err))
+	}
+	if err := logging.Setup(cfg.Logging); err != nil {
+		fatal("❌ Error configurando los logs", logging.Err(err))
 	}
 
-	apiKey := os.Getenv("API_MASTER_KEY")
-	if apiKey == "" {
-		log.Fatal("❌ API_MASTER_KEY is required in .env")
+	keys, err := cfg.Keys()
+	if err != nil {
+		fatal("❌ APP_SALT_KEYRING or APP_SALT_SECRET is invalid", logging.Err(err))
 	}
 
-	scyllaHost := os.Getenv("SCYLLA_HOST")
-	keyspace := os.Getenv("SCYLLA_KEYSPACE")
-	saltSecret := os.Getenv("APP_SALT_SECRET")
-	port := os.Getenv("HTTP_PORT")
+	slog.Info("🛡️  Iniciando Global Spam Registry (GSR)...")
+
+	// Background loops stop on background's cancel, after the HTTP server
+	// has drained; see the shutdown sequence at the end of main.
+	background, stopBackground := context.WithCancel(context.Background())
+	var loops sync.WaitGroup
 
-	if scyllaHost == "" {
-		scyllaHost = "localhost"
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		fatal("❌ Error configurando el trazado", logging.Err(err))
 	}
-	if port == "" {
-		port = ":8080"
+	defer shutdownTracing(context.Background())
+	if cfg.Tracing.Exporter != tracing.ExporterNone {
+		slog.Info("🔭 Trazas OpenTelemetry activadas", "exporter", cfg.Tracing.Exporter)
 	}
 
-	log.Println("🛡️  Iniciando Global Spam Registry (GSR)...")
-
-	session, err := scylla.Connect(keyspace, scyllaHost)
+	store, err := backend.Open(context.Background(), cfg.Storage)
 	if err != nil {
-		log.Fatalf("❌ Error conectando a ScyllaDB: %v", err)
+		fatal("❌ Error abriendo el almacenamiento", logging.Err(err))
+	}
+	defer store.Close()
+
+	if err := store.CheckSchema(context.Background()); err != nil {
+		fatal("❌ Esquema de base de datos incompatible", logging.Err(err))
+	}
+
+	slog.Info("💾 Almacenamiento listo", "storage", store.Kind)
+	repo := store.Repository
+
+	var sealer service.CommentSealer
+	if keyFile := cfg.CommentMasterKeyFile; keyFile != "" {
+		provider, err := envelope.NewFileKeyProvider(keyFile)
+		if err != nil {
+			fatal("❌ Error cargando la llave maestra de comentarios", logging.Err(err))
+		}
+		enc := envelope.NewEncrypter(provider, store.KeyStore)
+		repo = encrypted.NewCommentRepository(repo, enc)
+		sealer = encrypted.NewCommentSealer(enc)
+		slog.Info("🔐 Cifrado de comentarios activado")
+	}
+
+	if cacheCfg := cfg.Storage.ScoreCache; cacheCfg.Size > 0 {
+		cached := cache.NewRepository(repo, cache.NewLRU(cacheCfg.Size),
+			cache.WithTTL(cacheCfg.TTL), cache.WithNegativeTTL(cacheCfg.NegativeTTL))
+		repo = cached
+		slog.Info("⚡ Caché de scores activada", "size", cacheCfg.Size, "ttl", cacheCfg.TTL, "negative_ttl", cacheCfg.NegativeTTL)
+
+		metrics.RegisterCache(cached)
 	}
-	defer session.Close()
 
-	repo := scylla.NewScyllaRepository(session)
+	opts := []service.Option{service.WithObserver(metrics.Observer{})}
+	var snapshotFresh health.Check
+	if path := cfg.API.SnapshotPath; path != "" {
+		snapshot := blocklist.NewSnapshot()
+		if err := snapshot.Load(path); err != nil {
+			slog.Warn("⚠️  Sin snapshot de blocklist por ahora", logging.Err(err))
+		}
+		loops.Go(func() { snapshot.Run(background, path, time.Minute) })
+
+		opts = append(opts, service.WithSnapshot(snapshot))
+		snapshotFresh = health.Freshness("snapshot", snapshot.TakenAt, cfg.API.SnapshotMaxAge)
+		slog.Info("🛟 Modo degradado: las consultas usarán el snapshot si ScyllaDB no responde", "path", path)
+	}
+
+	readiness := newReadiness(store.Kind, store, snapshotFresh)
 
-	svc := service.NewReportService(repo, saltSecret)
+	var reportSpool *spool.Spool
+	if dir := cfg.API.SpoolDir; dir != "" {
+		reportSpool, err = spool.Open(dir)
+		if err != nil {
+			fatal("❌ Error abriendo el spool de reportes", logging.Err(err))
+		}
+		defer reportSpool.Close()
+
+		opts = append(opts, service.WithSpool(reportSpool, sealer))
+		metrics.RegisterSpool(reportSpool.Backlog)
+		readiness.Optional("report_spool", health.Backlog(reportSpool.Backlog, 64<<20))
+		slog.Info("📥 Spool de reportes activado", "dir", dir)
+	}
+
+	svc := tracing.NewService(service.NewReportService(repo, keys, opts...))
+	if reportSpool != nil {
+		loops.Go(func() { reportSpool.Run(background, 5*time.Second, svc.ReplaySpooled) })
+	}
 
 	handler := httpHandler.NewHandler(svc)
 
 	r := chi.NewRouter()
 
-	r.Use(chiMiddleware.Logger)
 	r.Use(chiMiddleware.Recoverer)
-	r.Use(middleware.APIKeyAuth(apiKey))
 
-	handler.RegisterRoutes(r)
+	// Probes and the API description stay outside the API key and out of
+	// the access log.
+	r.Get("/healthz", health.Liveness)
+	r.Get("/readyz", readiness.Readiness)
+	r.Get("/openapi.json", httpHandler.ServeOpenAPI)
+
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Tracing)
+		r.Use(chiMiddleware.RequestID)
+		r.Use(middleware.AccessLog(slog.Default()))
+		r.Use(middleware.Metrics)
+		r.Use(middleware.APIKeyAuth(cfg.API.MasterKey))
+
+		handler.RegisterRoutes(r)
+	})
+
+	metricsSrv := cfg.API.Server(cfg.API.MetricsAddr, metrics.Mux())
+	go func() {
+		slog.Info("📈 Métricas escuchando", "addr", metricsSrv.Addr)
+		if err := metricsSrv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			slog.Warn("⚠️  Servidor de métricas detenido", logging.Err(err))
+		}
+	}()
+
+	srv := cfg.API.Server(cfg.API.Addr, r)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("🚀 Servidor escuchando", "addr", cfg.API.Addr)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		fatal("❌ Error en el servidor HTTP", logging.Err(err))
+	case <-ctx.Done():
+	}
+	stop()
+
+	// Shutdown, in order: fail readiness and give the load balancer time to
+	// notice, stop accepting and drain requests in flight, close the metrics
+	// listener, stop the background loops, replay what is left in the spool,
+	// then let the defers close the spool, the store (the Scylla session) and
+	// flush the pending spans.
+	readiness.Drain()
+	slog.Info("🚦 Instancia marcada como no lista, esperando al balanceador...", "delay", cfg.API.DrainDelay)
+	time.Sleep(cfg.API.DrainDelay)
+
+	grace := cfg.API.ShutdownTimeout
+	slog.Info("🛑 Señal recibida, drenando conexiones...", "timeout", grace)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("⚠️  Conexiones cortadas al agotar el plazo", logging.Err(err))
+	}
+	if err := metricsSrv.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("⚠️  Servidor de métricas cortado al agotar el plazo", logging.Err(err))
+	}
+
+	stopBackground()
+	loops.Wait()
 
-	log.Printf("🚀 Servidor escuchando en http://localhost%s", port)
-	if err := http.ListenAndServe(port, r); err != nil {
-		log.Fatalf("❌ Error en el servidor HTTP: %v", err)
+	if reportSpool != nil {
+		n, err := reportSpool.Replay(shutdownCtx, svc.ReplaySpooled)
+		if err != nil {
+			slog.Warn("⚠️  Quedan reportes en el spool; se reenviarán al reiniciar", "replayed", n, logging.Err(err))
+		} else if n > 0 {
+			slog.Info("📤 Spool vaciado antes de salir", "replayed", n)
+		}
 	}
+
+	slog.Info("👋 Servidor detenido")
+}
+
+// fatal registra el error y termina el proceso sin ejecutar los defer.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
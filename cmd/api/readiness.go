@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rgdevment/spam-registry/internal/platform/health"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/scylla"
+)
+
+// storeProbe is what readiness asks of the store.
+type storeProbe interface {
+	Ping(ctx context.Context) error
+	CheckSchema(ctx context.Context) error
+}
+
+// newReadiness registers the store checks. snapshotFresh, when a blocklist
+// snapshot is configured, passes while the snapshot can answer lookups: only
+// then does a store outage degrade the instance instead of taking it out of
+// rotation. The report spool covers writes only, so it never does.
+func newReadiness(kind string, store storeProbe, snapshotFresh health.Check) *health.Checker {
+	readiness := health.New()
+
+	ping := func(ctx context.Context) (string, error) {
+		return kind, store.Ping(ctx)
+	}
+	if snapshotFresh != nil {
+		readiness.CriticalUnless("storage", ping, snapshotFresh)
+		readiness.Optional("blocklist_snapshot", snapshotFresh)
+	} else {
+		readiness.Critical("storage", ping)
+	}
+
+	// An unreachable store is the storage check's to report; schema only
+	// fails on a keyspace behind the binary.
+	readiness.Critical("schema", func(ctx context.Context) (string, error) {
+		err := store.CheckSchema(ctx)
+		if err != nil && !errors.Is(err, scylla.ErrSchemaOutdated) {
+			return "not verified: " + err.Error(), nil
+		}
+		return "", err
+	})
+	return readiness
+}
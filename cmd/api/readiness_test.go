@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rgdevment/spam-registry/internal/platform/health"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/scylla"
+)
+
+type fakeStore struct {
+	ping, schema error
+}
+
+func (s fakeStore) Ping(ctx context.Context) error        { return s.ping }
+func (s fakeStore) CheckSchema(ctx context.Context) error { return s.schema }
+
+var (
+	errNoHosts = errors.New("no hosts available")
+	down       = fakeStore{ping: errNoHosts, schema: errNoHosts}
+)
+
+func snapshot(err error) health.Check {
+	return func(context.Context) (string, error) { return "", err }
+}
+
+func TestStorageIsOnlyOptionalWhileAFreshSnapshotAnswers(t *testing.T) {
+	outdated := fmt.Errorf("%w (have 7, want 8)", scylla.ErrSchemaOutdated)
+
+	cases := []struct {
+		name     string
+		store    fakeStore
+		snapshot health.Check
+		want     string
+	}{
+		{"no fallback", down, nil, health.StatusDown},
+		{"spool only", down, nil, health.StatusDown},
+		{"fresh snapshot", down, snapshot(nil), health.StatusDegraded},
+		{"stale snapshot", down, snapshot(errors.New("snapshot is 3h old, over 1h")), health.StatusDown},
+		{"outdated schema", fakeStore{schema: outdated}, snapshot(nil), health.StatusDown},
+		{"healthy", fakeStore{}, nil, health.StatusOK},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			report := newReadiness("scylla", tc.store, tc.snapshot).Run(context.Background())
+			assert.Equal(t, tc.want, report.Status)
+		})
+	}
+}
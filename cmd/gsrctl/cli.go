@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/rgdevment/spam-registry/internal/platform/config"
+	"github.com/rgdevment/spam-registry/internal/platform/crypto/envelope"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/backend"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/encrypted"
+	"github.com/rgdevment/spam-registry/internal/service"
+	"github.com/rgdevment/spam-registry/pkg/client"
+)
+
+// cli holds what the commands share: the settings, the output and, opened
+// on first use, either the store or the HTTP API client.
+type cli struct {
+	cfg config.Config
+	out io.Writer
+
+	api   *client.Client
+	store *backend.Backend
+	repo  service.Repository
+}
+
+func newCLI(cfg config.Config, out io.Writer) (*cli, error) {
+	c := &cli{cfg: cfg, out: out}
+	if cfg.CLI.APIURL != "" {
+		var err error
+		if c.api, err = client.New(cfg.CLI.APIURL, cfg.API.MasterKey, client.WithUserAgent("gsrctl")); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// remote reports whether the command should go through the HTTP API.
+func (c *cli) remote() bool {
+	return c.api != nil
+}
+
+// repository opens the configured store. Comments are decrypted when a
+// master key is configured, as in the API and the worker.
+func (c *cli) repository(ctx context.Context, command string) (service.Repository, error) {
+	if c.remote() {
+		return nil, fmt.Errorf("%s needs direct store access; unset GSR_API_URL", command)
+	}
+	if c.repo != nil {
+		return c.repo, nil
+	}
+
+	store, err := backend.Open(ctx, c.cfg.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.CheckSchema(ctx); err != nil {
+		store.Close()
+		return nil, err
+	}
+	c.store = store
+	log.Printf("💾 Almacenamiento %s abierto", store.Kind)
+
+	repo := store.Repository
+	if keyFile := c.cfg.CommentMasterKeyFile; keyFile != "" {
+		provider, err := envelope.NewFileKeyProvider(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		repo = encrypted.NewCommentRepository(repo, envelope.NewEncrypter(provider, store.KeyStore))
+	}
+	c.repo = repo
+
+	return repo, nil
+}
+
+// service builds the report service over the store. The reporter keyring is
+// only loaded when withKeys is set: reading and scoring never hash reporters.
+func (c *cli) service(ctx context.Context, command string, withKeys bool) (service.Service, error) {
+	var keys *service.Keyring
+	if withKeys {
+		var err error
+		if keys, err = c.cfg.Keys(); err != nil {
+			return nil, err
+		}
+	}
+
+	repo, err := c.repository(ctx, command)
+	if err != nil {
+		return nil, err
+	}
+	return service.NewReportService(repo, keys), nil
+}
+
+func (c *cli) Close() {
+	if c.store != nil {
+		c.store.Close()
+		c.store = nil
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+	"github.com/rgdevment/spam-registry/internal/platform/logging"
+	"github.com/rgdevment/spam-registry/internal/service"
+)
+
+func runLookup(ctx context.Context, c *cli, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: gsrctl lookup <phone>")
+	}
+	phone := args[0]
+
+	var (
+		score *domain.PhoneScore
+		err   error
+	)
+	if c.remote() {
+		score, err = c.api.Lookup(ctx, phone)
+	} else {
+		var svc service.Service
+		if svc, err = c.service(ctx, "lookup", false); err != nil {
+			return err
+		}
+		score, err = svc.CheckRisk(ctx, phone)
+	}
+	if err != nil {
+		return err
+	}
+	if score == nil {
+		fmt.Fprintf(c.out, "%s has no score\n", phone)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "phone\t%s\n", score.PhoneNumber)
+	fmt.Fprintf(w, "country\t%s\n", score.CountryCode)
+	fmt.Fprintf(w, "score\t%.2f\n", score.Score)
+	fmt.Fprintf(w, "risk level\t%s\n", score.RiskLevel)
+	fmt.Fprintf(w, "reports\t%d\n", score.TotalReports)
+	fmt.Fprintf(w, "velocity hits\t%d\n", score.VelocityHitCount)
+	if !score.LastActivity.IsZero() {
+		fmt.Fprintf(w, "last activity\t%s\n", score.LastActivity.Format(time.RFC3339))
+	}
+	if score.Stale {
+		fmt.Fprintf(w, "stale\tanswered from the snapshot of %s\n", score.SnapshotAt.Format(time.RFC3339))
+	}
+	return w.Flush()
+}
+
+func runExplain(ctx context.Context, c *cli, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: gsrctl explain <phone>")
+	}
+
+	svc, err := c.service(ctx, "explain", false)
+	if err != nil {
+		return err
+	}
+	ex, err := svc.ExplainRisk(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "phone\t%s (%s)\n", ex.PhoneNumber, ex.CountryCode)
+	fmt.Fprintf(w, "as of\t%s\n", ex.Now.Format(time.RFC3339))
+	fmt.Fprintf(w, "reports read\t%d\n", ex.TotalReports)
+	if !ex.LastHumanActivity.IsZero() {
+		fmt.Fprintf(w, "last human report\t%s\n", ex.LastHumanActivity.Format(time.RFC3339))
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "CATEGORY\tDECAYED REPORTS\tWEIGHT\tPOINTS")
+	for _, cat := range ex.Categories {
+		fmt.Fprintf(w, "%s\t%.2f\t%.1f\t%.2f\n", cat.Category, cat.DecayedReports, cat.Weight, cat.Points)
+	}
+	fmt.Fprintf(w, "raw score\t\t\t%.2f\n", ex.RawScore)
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "reporters\t%d (consensus x%.2f)\n", ex.Reporters, ex.ConsensusFactor)
+	fmt.Fprintf(w, "auto blocks\t%d (velocity floor: %t)\n", ex.AutoBlocks, ex.VelocityFloor)
+	fmt.Fprintf(w, "score\t%.2f %s\n", ex.Score, ex.RiskLevel)
+	if ex.Dropped {
+		fmt.Fprintln(w, "stored\tno: too low to keep, the number would be dropped")
+	}
+	return w.Flush()
+}
+
+func runRecalc(ctx context.Context, c *cli, args []string) error {
+	fs := flag.NewFlagSet("recalc", flag.ContinueOnError)
+	country := fs.String("country", "", "recalculate the active threats of this ISO country code")
+	all := fs.Bool("all", false, "recalculate the active threats of every country")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var phone string
+	switch {
+	case fs.NArg() == 1 && *country == "" && !*all:
+		phone = fs.Arg(0)
+	case fs.NArg() == 0 && (*country != "") != *all:
+	default:
+		return errors.New("usage: gsrctl recalc <phone> | -country CC | -all")
+	}
+
+	svc, err := c.service(ctx, "recalc", false)
+	if err != nil {
+		return err
+	}
+
+	if phone != "" {
+		if err := svc.CalculateAndSaveRisk(ctx, phone); err != nil {
+			return err
+		}
+		return runLookup(ctx, c, []string{phone})
+	}
+
+	// The threat index is the only listing of numbers: recalculating a
+	// country revisits every number with a stored score.
+	repo, err := c.repository(ctx, "recalc")
+	if err != nil {
+		return err
+	}
+	countries := []string{strings.ToUpper(*country)}
+	if *all {
+		countries = service.ThreatRegions()
+	}
+
+	start := time.Now()
+	var done, failed int
+	for _, cc := range countries {
+		threats, err := repo.ListCountryThreats(ctx, cc)
+		if err != nil {
+			return fmt.Errorf("listing threats of %s: %w", cc, err)
+		}
+		for _, threat := range threats {
+			if err := svc.CalculateAndSaveRisk(ctx, threat.PhoneNumber); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				failed++
+				slog.Warn("⚠️  Recálculo fallido", logging.Phone("phone", threat.PhoneNumber), logging.Err(err))
+				continue
+			}
+			done++
+		}
+		if len(threats) > 0 {
+			log.Printf("🔄 %s: %d amenazas recalculadas", cc, len(threats))
+		}
+	}
+
+	fmt.Fprintf(c.out, "recalculated %d numbers, %d failed, in %s\n", done, failed, elapsed(start))
+	if failed > 0 {
+		return fmt.Errorf("%d numbers failed to recalculate", failed)
+	}
+	return nil
+}
+
+func runReports(ctx context.Context, c *cli, args []string) error {
+	if len(args) < 1 || args[0] != "list" {
+		return errors.New("usage: gsrctl reports list [-limit N] <phone>")
+	}
+
+	fs := flag.NewFlagSet("reports list", flag.ContinueOnError)
+	limit := fs.Int("limit", 20, "reports to show, newest first")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || *limit < 1 {
+		return errors.New("usage: gsrctl reports list [-limit N] <phone>")
+	}
+	phone := fs.Arg(0)
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+
+	// Through the API only the public, moderated view is available.
+	if c.remote() {
+		page, err := c.api.ListReports(ctx, phone, "", 1, *limit)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, "AGE\tCATEGORY\tLANG\tCOMMENT")
+		for _, r := range page.Reports {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.AgeBucket, r.Category, r.Lang, oneLine(r.Comment))
+		}
+		return w.Flush()
+	}
+
+	repo, err := c.repository(ctx, "reports list")
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, "CREATED\tCATEGORY\tMODERATION\tLANG\tREPORTER\tCOMMENT")
+	n := 0
+	for r, err := range repo.StreamRawReports(ctx, phone, service.ReportRange{PageSize: *limit}) {
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s/v%d\t%s\n",
+			r.CreatedAt.Format(time.RFC3339), r.Category, r.ModerationStatus, r.Lang,
+			shortHash(r.ReporterHash), r.ReporterKeyVersion, oneLine(r.Comment))
+		if n++; n == *limit {
+			break
+		}
+	}
+	return w.Flush()
+}
+
+// shortHash keeps enough of a reporter hash to tell reporters apart.
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
+
+// oneLine fits a comment on a table row.
+func oneLine(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > 60 {
+		return string(r[:59]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nyaruka/phonenumbers"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+	"github.com/rgdevment/spam-registry/internal/platform/blocklist"
+	httpHandler "github.com/rgdevment/spam-registry/internal/platform/http"
+	"github.com/rgdevment/spam-registry/internal/platform/logging"
+	"github.com/rgdevment/spam-registry/internal/service"
+	"github.com/rgdevment/spam-registry/pkg/client"
+)
+
+// importRecord is one line of an import file: the body of POST /v1/reports
+// plus the reporter the API would read from X-Reporter-ID.
+type importRecord struct {
+	httpHandler.CreateReportRequest
+	ReporterID string `json:"reporter_id,omitempty"`
+}
+
+func runExport(ctx context.Context, c *cli, args []string) error {
+	if len(args) != 2 || args[0] != "blocklist" {
+		return errors.New("usage: gsrctl export blocklist <file>")
+	}
+
+	repo, err := c.repository(ctx, "export")
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	count, err := blocklist.Export(ctx, repo, args[1])
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "exported %d threats to %s in %s\n", count, args[1], elapsed(start))
+	return nil
+}
+
+// runImport ingests reports exactly as the API would, line by line. A bad
+// line is reported and skipped; the command fails if any line did.
+func runImport(ctx context.Context, c *cli, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: gsrctl import <file.jsonl|->")
+	}
+
+	var in io.Reader = os.Stdin
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	// Ingesting never scores. Over the API the numbers wait for the worker;
+	// directly they are recalculated once the whole file is in.
+	var svc service.Service
+	touched := make(map[string]bool)
+	report := func(ctx context.Context, rec importRecord) error {
+		return c.api.ReportNumber(ctx, client.Report{
+			PhoneNumber: rec.PhoneNumber,
+			Category:    client.Category(rec.Category),
+			Comment:     rec.Comment,
+			Lang:        rec.Lang,
+			ReporterID:  rec.ReporterID,
+		})
+	}
+	if !c.remote() {
+		var err error
+		if svc, err = c.service(ctx, "import", true); err != nil {
+			return err
+		}
+		report = func(ctx context.Context, rec importRecord) error {
+			if err := svc.IngestReport(ctx, rec.PhoneNumber, rec.ReporterID, rec.Category, rec.Comment, rec.Lang); err != nil {
+				return err
+			}
+			// Scores are keyed by the E.164 form the service stored, which
+			// the line may have spelled differently.
+			num, err := phonenumbers.Parse(rec.PhoneNumber, "")
+			if err != nil {
+				return err
+			}
+			touched[phonenumbers.Format(num, phonenumbers.E164)] = true
+			return nil
+		}
+	}
+
+	start := time.Now()
+	var line, imported, failed int
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64<<10), httpHandler.MaxJSONBodyBytes)
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var rec importRecord
+		err := json.Unmarshal(scanner.Bytes(), &rec)
+		if err == nil {
+			err = rec.Validate()
+		}
+		if err == nil {
+			if rec.ReporterID == "" {
+				rec.ReporterID = "anonymous"
+			}
+			err = report(ctx, rec)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			failed++
+			log.Printf("⚠️  línea %d: %v", line, err)
+			continue
+		}
+		imported++
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("line %d: %w", line+1, err)
+	}
+
+	for phone := range touched {
+		if err := svc.CalculateAndSaveRisk(ctx, phone); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			failed++
+			slog.Warn("⚠️  Recálculo fallido", logging.Phone("phone", phone), logging.Err(err))
+		}
+	}
+
+	fmt.Fprintf(c.out, "imported %d reports, recalculated %d numbers, %d failed, in %s\n", imported, len(touched), failed, elapsed(start))
+	if failed > 0 {
+		return fmt.Errorf("%d lines or numbers failed to import", failed)
+	}
+	return nil
+}
+
+// runStats counts the threat index per country. Numbers whose score decayed
+// out of the index are not counted.
+func runStats(ctx context.Context, c *cli, args []string) error {
+	if len(args) != 0 {
+		return errors.New("usage: gsrctl stats")
+	}
+
+	repo, err := c.repository(ctx, "stats")
+	if err != nil {
+		return err
+	}
+
+	levels := []domain.RiskLevel{domain.LevelCritical, domain.LevelWarning, domain.LevelSafe}
+	totals := make(map[domain.RiskLevel]int)
+	total := 0
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "COUNTRY\tCRITICAL\tWARNING\tSAFE\tTOTAL\t")
+	for _, cc := range service.ThreatRegions() {
+		threats, err := repo.ListCountryThreats(ctx, cc)
+		if err != nil {
+			return fmt.Errorf("listing threats of %s: %w", cc, err)
+		}
+		if len(threats) == 0 {
+			continue
+		}
+
+		counts := make(map[domain.RiskLevel]int)
+		for _, t := range threats {
+			counts[t.RiskLevel]++
+			totals[t.RiskLevel]++
+		}
+		fmt.Fprintf(w, "%s\t", cc)
+		for _, level := range levels {
+			fmt.Fprintf(w, "%d\t", counts[level])
+		}
+		fmt.Fprintf(w, "%d\t\n", len(threats))
+		total += len(threats)
+	}
+
+	fmt.Fprint(w, "all\t")
+	for _, level := range levels {
+		fmt.Fprintf(w, "%d\t", totals[level])
+	}
+	fmt.Fprintf(w, "%d\t\n", total)
+	return w.Flush()
+}
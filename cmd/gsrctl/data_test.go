@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+	"github.com/rgdevment/spam-registry/internal/platform/blocklist"
+	"github.com/rgdevment/spam-registry/internal/platform/config"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/memory"
+)
+
+func TestImportRecalculatesNumbersUnderTheirE164Form(t *testing.T) {
+	ctx := context.Background()
+	lines := `{"phone_number": "+56 9 8765 4321", "category": "FRAUD", "reporter_id": "user_A"}
+{"phone_number": "+56-987-654-321", "category": "FRAUD", "reporter_id": "user_B"}
+{"phone_number": "+56987654321", "category": "FRAUD", "reporter_id": "user_C"}
+`
+	path := filepath.Join(t.TempDir(), "reports.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(lines), 0o600))
+
+	repo := memory.NewMemoryRepository()
+	var out bytes.Buffer
+	c := &cli{cfg: config.Config{SaltSecret: "secret_salt"}, out: &out, repo: repo}
+
+	require.NoError(t, runImport(ctx, c, []string{path}))
+	assert.Contains(t, out.String(), "imported 3 reports, recalculated 1 numbers, 0 failed")
+
+	score, err := repo.GetScore(ctx, "+56987654321")
+	require.NoError(t, err)
+	require.NotNil(t, score, "the score is stored under the E.164 number")
+	assert.Equal(t, domain.LevelCritical, score.RiskLevel)
+	assert.Equal(t, 3, score.TotalReports)
+}
+
+func TestStatsCountsEveryRegionTheBlocklistExports(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMemoryRepository()
+	for phone, country := range map[string]string{"+56911111111": "CL", "+80012345678": "001", "+99912345678": "XX"} {
+		require.NoError(t, repo.UpsertCountryThreat(ctx, &domain.PhoneScore{
+			PhoneNumber: phone, CountryCode: country, Score: 70, RiskLevel: domain.LevelCritical,
+		}, 3600))
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, runStats(ctx, &cli{out: &out, repo: repo}, nil))
+
+	exported, err := blocklist.Export(ctx, repo, filepath.Join(t.TempDir(), "blocklist.json"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, exported)
+	assert.Regexp(t, `all\s+3\s+0\s+0\s+3`, out.String(), "stats and the export agree")
+	assert.Regexp(t, `XX\s+1`, out.String())
+	assert.Regexp(t, `001\s+1`, out.String())
+}
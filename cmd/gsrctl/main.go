@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rgdevment/spam-registry/internal/platform/config"
+)
+
+const usage = `gsrctl administers a Global Spam Registry deployment.
+
+Usage: gsrctl [settings] <command> [arguments]
+
+Day to day:
+  lookup <phone>                       show the stored score of a number
+  explain <phone>                      break down the score the scoring would give now
+  recalc <phone>                       recalculate one number
+  recalc -country CC | -all            recalculate the active threats of a country, or of all
+  reports list [-limit N] <phone>      list the most recent reports of a number
+
+Administration:
+  migrate up|status                    apply or list ScyllaDB schema migrations
+  cluster health                       show node state and LOCAL_QUORUM availability per DC
+
+Data:
+  export blocklist <file>              write every active threat for the API's degraded mode
+  import <file.jsonl|->                ingest reports, one JSON object per line
+  stats                                count active threats per country and risk level
+
+Commands open the configured store directly. With -gsr-api-url (GSR_API_URL),
+lookup, reports list and import go through the HTTP API instead, authenticated
+with API_MASTER_KEY.
+
+Settings come from flags, the environment (or .env) and -config, as for the
+API and the worker; run with -help to list them.
+`
+
+func main() {
+	fs := flag.NewFlagSet("gsrctl", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, usage, "\nSettings:\n")
+		fs.PrintDefaults()
+	}
+	timeout := fs.Duration("timeout", 0, "Abort the command after this long, 0 waits until it finishes")
+
+	cfg, err := config.Load(config.CLI, fs, os.Args[1:])
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	args := fs.Args()
+	if len(args) < 1 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	c, err := newCLI(cfg, os.Stdout)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	defer c.Close()
+
+	switch args[0] {
+	case "lookup":
+		err = runLookup(ctx, c, args[1:])
+	case "explain":
+		err = runExplain(ctx, c, args[1:])
+	case "recalc":
+		err = runRecalc(ctx, c, args[1:])
+	case "reports":
+		err = runReports(ctx, c, args[1:])
+	case "migrate":
+		err = runMigrate(ctx, cfg, args[1:])
+	case "cluster":
+		err = runCluster(ctx, cfg, args[1:])
+	case "export":
+		err = runExport(ctx, c, args[1:])
+	case "import":
+		err = runImport(ctx, c, args[1:])
+	case "stats":
+		err = runStats(ctx, c, args[1:])
+	case "help":
+		fmt.Print(usage)
+		return
+	default:
+		err = fmt.Errorf("unknown command %q", args[0])
+	}
+
+	if err != nil {
+		c.Close()
+		log.Fatalf("❌ %v", err)
+	}
+}
+
+// elapsed formats a duration for progress lines.
+func elapsed(since time.Time) string {
+	return time.Since(since).Round(time.Millisecond).String()
+}
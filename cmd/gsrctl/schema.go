@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rgdevment/spam-registry/internal/platform/config"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/scylla"
+)
+
+func runMigrate(ctx context.Context, settings config.Config, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: gsrctl migrate up|status")
+	}
+
+	cfg := settings.Storage.Scylla
+
+	switch args[0] {
+	case "up":
+		bootstrapCfg := cfg
+		bootstrapCfg.Keyspace = ""
+
+		bootstrap, err := scylla.NewSession(bootstrapCfg)
+		if err != nil {
+			return err
+		}
+		altered, err := scylla.EnsureKeyspace(ctx, bootstrap, cfg.Keyspace, cfg.Replication)
+		bootstrap.Close()
+		if err != nil {
+			return err
+		}
+		if altered {
+			log.Printf("⚠️  Replicación de %s cambiada a %s: ejecuta `nodetool repair -full %s` en cada DC", cfg.Keyspace, cfg.Replication, cfg.Keyspace)
+		}
+
+		session, err := scylla.NewSession(cfg)
+		if err != nil {
+			return err
+		}
+		defer session.Close()
+
+		applied, err := scylla.Migrate(ctx, session)
+		for _, m := range applied {
+			log.Printf("✅ Aplicada %s", m.Name)
+		}
+		if err != nil {
+			return err
+		}
+
+		log.Printf("🏁 Esquema en la versión %d (%d migraciones nuevas)", scylla.ExpectedSchemaVersion(), len(applied))
+		return nil
+
+	case "status":
+		session, err := scylla.NewSession(cfg)
+		if err != nil {
+			return err
+		}
+		defer session.Close()
+
+		states, err := scylla.MigrationStatus(ctx, session)
+		if err != nil {
+			return err
+		}
+
+		for _, s := range states {
+			status := "pending"
+			if s.Applied {
+				status = "applied " + s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%03d  %-32s %s\n", s.Version, s.Name, status)
+		}
+
+		return scylla.VerifySchema(ctx, session)
+
+	default:
+		return fmt.Errorf("unknown migrate action %q (use up or status)", args[0])
+	}
+}
+
+func runCluster(ctx context.Context, settings config.Config, args []string) error {
+	if len(args) != 1 || args[0] != "health" {
+		return fmt.Errorf("usage: gsrctl cluster health")
+	}
+
+	storageCfg := settings.Storage
+	session, err := scylla.NewSession(storageCfg.Scylla)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	health, err := scylla.ReplicaHealth(ctx, session, storageCfg.Scylla.Keyspace)
+	if err != nil {
+		return err
+	}
+
+	degraded := false
+	for _, dc := range health {
+		quorum := "LOCAL_QUORUM ok"
+		if !dc.LocalQuorum {
+			quorum = "LOCAL_QUORUM unavailable"
+			degraded = true
+		}
+		fmt.Printf("%-12s rf=%d up=%d/%d  %s\n", dc.Name, dc.ReplicationFactor, dc.Up, len(dc.Nodes), quorum)
+		for _, node := range dc.Nodes {
+			state := "UP"
+			if !node.Up {
+				state = "DOWN"
+			}
+			fmt.Printf("  %-39s %s\n", node.Address, state)
+		}
+	}
+
+	if degraded {
+		return fmt.Errorf("some datacenters cannot serve LOCAL_QUORUM")
+	}
+	return nil
+}
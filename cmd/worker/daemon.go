@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/rgdevment/spam-registry/internal/platform/blocklist"
+	"github.com/rgdevment/spam-registry/internal/platform/health"
+	"github.com/rgdevment/spam-registry/internal/platform/logging"
+	"github.com/rgdevment/spam-registry/internal/platform/metrics"
+	"github.com/rgdevment/spam-registry/internal/service"
+)
+
+// runDaemon keeps active threats fresh: scores only decay when they are
+// recalculated, so every pass recalculates each number in the threat index.
+func runDaemon(interval time.Duration, exportPath string) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	keys := loadKeyring()
+	store := openStore()
+	defer store.Close()
+
+	svc := newService(store, keys)
+
+	var lastPass atomic.Int64
+	readiness := health.New()
+	readiness.Critical("storage", func(ctx context.Context) (string, error) {
+		return store.Kind, store.Ping(ctx)
+	})
+	readiness.Critical("schema", func(ctx context.Context) (string, error) {
+		return "", store.CheckSchema(ctx)
+	})
+	// A pass can take a while; only flag the daemon once it missed one.
+	readiness.Optional("last_pass", health.Freshness("completed pass", func() time.Time {
+		if n := lastPass.Load(); n != 0 {
+			return time.Unix(0, n)
+		}
+		return time.Time{}
+	}, 2*interval))
+
+	mux := metrics.Mux()
+	mux.HandleFunc("/healthz", health.Liveness)
+	mux.HandleFunc("/readyz", readiness.Readiness)
+	srv := cfg.API.Server(cfg.Worker.MetricsAddr, mux)
+	go func() {
+		slog.Info("📈 Metrics and probes listening", "addr", srv.Addr)
+		if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			slog.Warn("⚠️  Metrics server stopped", logging.Err(err))
+		}
+	}()
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.API.ShutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			slog.Warn("⚠️  Metrics server cut off at the shutdown timeout", logging.Err(err))
+		}
+	}()
+
+	slog.Info("🐝 GSR Worker daemon started", "interval", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if refreshThreats(ctx, svc, store.Repository) {
+			lastPass.Store(time.Now().UnixNano())
+		}
+
+		if exportPath != "" && ctx.Err() == nil {
+			count, err := blocklist.Export(ctx, store.Repository, exportPath)
+			if err != nil {
+				slog.ErrorContext(ctx, "❌ Export Failed", logging.Err(err))
+			} else {
+				slog.InfoContext(ctx, "📤 Blocklist exported", "count", count, "path", exportPath)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Info("👋 Worker daemon stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshThreats reports whether the pass ran to the end.
+func refreshThreats(ctx context.Context, svc service.Service, repo service.Repository) bool {
+	start := time.Now()
+	recalculated, failed := 0, 0
+
+	for _, region := range service.ThreatRegions() {
+		threats, err := repo.ListCountryThreats(ctx, region)
+		if err != nil {
+			slog.ErrorContext(ctx, "❌ Listing threats failed", "country", region, logging.Err(err))
+			failed++
+			continue
+		}
+
+		for _, threat := range threats {
+			if ctx.Err() != nil {
+				return false
+			}
+			if err := svc.CalculateAndSaveRisk(ctx, threat.PhoneNumber); err != nil {
+				slog.ErrorContext(ctx, "❌ Calculation Failed", logging.Phone("phone", threat.PhoneNumber), logging.Err(err))
+				failed++
+				continue
+			}
+			recalculated++
+		}
+	}
+
+	slog.InfoContext(ctx, "✅ Pass done",
+		"duration", time.Since(start).Round(time.Millisecond), "recalculated", recalculated, "failed", failed)
+	return true
+}
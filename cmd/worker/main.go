@@ -3,49 +3,176 @@ package main
 import (
 	"context"
 	"flag"
-	"log"
+	"log/slog"
 	"os"
+	"time"
 
-	"github.com/joho/godotenv"
-	"github.com/rgdevment/spam-registry/internal/platform/storage/scylla"
+	"github.com/rgdevment/spam-registry/internal/platform/blocklist"
+	"github.com/rgdevment/spam-registry/internal/platform/config"
+	"github.com/rgdevment/spam-registry/internal/platform/crypto/envelope"
+	"github.com/rgdevment/spam-registry/internal/platform/logging"
+	"github.com/rgdevment/spam-registry/internal/platform/metrics"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/backend"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/encrypted"
+	"github.com/rgdevment/spam-registry/internal/platform/tracing"
 	"github.com/rgdevment/spam-registry/internal/service"
 )
 
+// cfg is the effective configuration, loaded once by main.
+var cfg config.Config
+
 func main() {
-	if err := godotenv.Load(); err != nil {
-		log.Println("⚠️  No .env file found, using system environment variables")
+	phonePtr := flag.String("phone", "", "The phone number to recalculate risk for (E.164 format)")
+	rehashPtr := flag.Bool("rehash", false, "Move the number's reports onto the active reporter key before recalculating")
+	rebuildPtr := flag.Bool("rebuild", false, "Rebuild the number's aggregate from its reports before recalculating")
+	shredPtr := flag.String("shred-comments", "", "Destroy the comment data key of a month (YYYY-MM), making its comments unreadable")
+	exportPtr := flag.String("export-blocklist", "", "Write every active threat to this file, for the API's degraded mode")
+	daemonPtr := flag.Bool("daemon", false, "Keep running: recalculate every active threat each -interval (and export the blocklist if asked), serving /metrics")
+	intervalPtr := flag.Duration("interval", 15*time.Minute, "Time between daemon passes")
+
+	var err error
+	cfg, err = config.Load(config.Worker, flag.CommandLine, os.Args[1:])
+	if err != nil {
+		fatal("❌ Invalid configuration", logging.Err(err))
+	}
+	if err := logging.Setup(cfg.Logging); err != nil {
+		fatal("❌ Logging setup failed", logging.Err(err))
 	}
 
-	phonePtr := flag.String("phone", "", "The phone number to recalculate risk for (E.164 format)")
-	flag.Parse()
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		fatal("❌ Tracing setup failed", logging.Err(err))
+	}
+	defer shutdownTracing(context.Background())
+
+	if *daemonPtr {
+		runDaemon(*intervalPtr, *exportPtr)
+		return
+	}
+
+	if *shredPtr != "" {
+		shredComments(*shredPtr)
+		return
+	}
+
+	if *exportPtr != "" {
+		exportBlocklist(*exportPtr)
+		return
+	}
 
 	if *phonePtr == "" {
-		log.Fatal("❌ Error: You must provide a phone number.\nUsage: go run cmd/worker/main.go -phone=+56912345678")
+		fatal("❌ Error: You must provide a phone number. Usage: go run ./cmd/worker -phone=+56912345678")
+	}
+
+	slog.Info("🐝 GSR Worker Starting manually", logging.Phone("phone", *phonePtr))
+
+	keys := loadKeyring()
+
+	store := openStore()
+	defer store.Close()
+
+	svc := newService(store, keys)
+
+	if *rehashPtr {
+		slog.Info("🔑 Rehashing reporters", "key_version", keys.ActiveVersion())
+		updated, err := svc.RehashReporters(context.Background(), *phonePtr)
+		if err != nil {
+			fatal("❌ Rehash Failed", logging.Phone("phone", *phonePtr), logging.Err(err))
+		}
+		slog.Info("✅ Reports rehashed", "count", updated)
+	}
+
+	if *rebuildPtr {
+		slog.Info("🧮 Rebuilding aggregate")
+		if err := svc.RebuildAggregate(context.Background(), *phonePtr); err != nil {
+			fatal("❌ Rebuild Failed", logging.Phone("phone", *phonePtr), logging.Err(err))
+		}
+	}
+
+	slog.Info("🧠 Running Quantum Algorithm...")
+	if err := svc.CalculateAndSaveRisk(context.Background(), *phonePtr); err != nil {
+		fatal("❌ Calculation Failed", logging.Phone("phone", *phonePtr), logging.Err(err))
+	}
+
+	slog.Info("✅ Success! Score updated (Scores & Active Threats tables).", "storage", store.Kind)
+}
+
+func commentEncrypter(keyStore envelope.KeyStore) *envelope.Encrypter {
+	keyFile := cfg.CommentMasterKeyFile
+	if keyFile == "" {
+		return nil
 	}
 
-	log.Printf("🐝 GSR Worker Starting manually for target: %s", *phonePtr)
+	provider, err := envelope.NewFileKeyProvider(keyFile)
+	if err != nil {
+		fatal("❌ Comment master key", logging.Err(err))
+	}
+
+	return envelope.NewEncrypter(provider, keyStore)
+}
 
-	scyllaHost := os.Getenv("SCYLLA_HOST")
-	keyspace := os.Getenv("SCYLLA_KEYSPACE")
-	if scyllaHost == "" {
-		scyllaHost = "localhost"
+func loadKeyring() *service.Keyring {
+	keys, err := cfg.Keys()
+	if err != nil {
+		fatal("❌ APP_SALT_KEYRING or APP_SALT_SECRET is invalid", logging.Err(err))
+	}
+	return keys
+}
+
+func newService(store *backend.Backend, keys *service.Keyring) service.Service {
+	repo := store.Repository
+	if enc := commentEncrypter(store.KeyStore); enc != nil {
+		repo = encrypted.NewCommentRepository(repo, enc)
 	}
 
-	session, err := scylla.Connect(keyspace, scyllaHost)
+	return tracing.NewService(service.NewReportService(repo, keys, service.WithObserver(metrics.Observer{})))
+}
+
+func openStore() *backend.Backend {
+	store, err := backend.Open(context.Background(), cfg.Storage)
 	if err != nil {
-		log.Fatalf("❌ DB Connection Failed: %v", err)
+		fatal("❌ DB Connection Failed", logging.Err(err))
+	}
+	return store
+}
+
+func shredComments(monthStr string) {
+	month, err := time.Parse("2006-01", monthStr)
+	if err != nil {
+		fatal("❌ Invalid month, expected YYYY-MM", "month", monthStr)
+	}
+
+	store := openStore()
+	defer store.Close()
+
+	enc := commentEncrypter(store.KeyStore)
+	if enc == nil {
+		fatal("❌ COMMENT_MASTER_KEY_FILE is required to shred comments")
+	}
+
+	slog.Info("🔥 Shredding comment key...", "month", month.Format("2006-01"))
+	if err := enc.Shred(context.Background(), month); err != nil {
+		fatal("❌ Shred Failed", logging.Err(err))
 	}
-	defer session.Close()
 
-	repo := scylla.NewScyllaRepository(session)
+	slog.Info("✅ Comments for that month are now unreadable.")
+}
 
-	svc := service.NewReportService(repo, "")
+func exportBlocklist(path string) {
+	store := openStore()
+	defer store.Close()
 
-	log.Println("🧠 Running Quantum Algorithm...")
-	err = svc.CalculateAndSaveRisk(context.Background(), *phonePtr)
+	slog.Info("📤 Exporting blocklist...", "path", path)
+	count, err := blocklist.Export(context.Background(), store.Repository, path)
 	if err != nil {
-		log.Fatalf("❌ Calculation Failed: %v", err)
+		fatal("❌ Export Failed", logging.Err(err))
 	}
 
-	log.Println("✅ Success! Score updated in ScyllaDB (Scores & Active Threats tables).")
+	slog.Info("✅ Numbers exported.", "count", count)
+}
+
+// fatal logs at error level and exits. Deferred calls do not run.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
@@ -0,0 +1,41 @@
+package domain
+
+import "time"
+
+// PhoneAggregate is the running state scoring needs for one number. It is
+// updated on every ingest so a recalculation does not reread the history.
+type PhoneAggregate struct {
+	PhoneNumber string `json:"phone_number" db:"phone_number"`
+	CountryCode string `json:"country_code" db:"country_code"`
+
+	// CategoryDecay holds, per category, the sum of the decay factors of its
+	// reports as of DecayedAt. Weights are applied when scoring.
+	CategoryDecay map[RiskCategory]float64 `json:"category_decay" db:"category_decay"`
+	DecayedAt     time.Time                `json:"decayed_at" db:"decayed_at"`
+
+	// ReporterSketch is a HyperLogLog of the human reporters.
+	ReporterSketch []byte `json:"reporter_sketch" db:"reporter_sketch"`
+
+	// AutoBlockDays counts AUTO_BLOCK reports per UTC day (see DayBucket).
+	// Only the velocity window is kept.
+	AutoBlockDays map[string]int `json:"auto_block_days" db:"auto_block_days"`
+
+	LastHumanActivity time.Time `json:"last_human_activity" db:"last_human_activity"`
+	TotalReports      int       `json:"total_reports" db:"total_reports"`
+
+	// Version increments on every write; 0 means nothing is stored yet.
+	Version int64 `json:"version" db:"version"`
+}
+
+func NewPhoneAggregate(phoneNumber string) *PhoneAggregate {
+	return &PhoneAggregate{
+		PhoneNumber:   phoneNumber,
+		CategoryDecay: make(map[RiskCategory]float64),
+		AutoBlockDays: make(map[string]int),
+	}
+}
+
+// DayBucket is the AutoBlockDays key of t.
+func DayBucket(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Range lookups work like the HIBP k-anonymity API: the client only sends the
+// first characters of SHA-256(E.164) and matches the full hash locally.
+const (
+	MinHashPrefixLength = 4
+	MaxHashPrefixLength = 8
+)
+
+type HashedScore struct {
+	PhoneHash string    `json:"phone_hash" db:"phone_hash"` // hex SHA-256 of the E.164 number
+	Score     float64   `json:"score" db:"score"`
+	RiskLevel RiskLevel `json:"risk_level" db:"risk_level"`
+}
+
+func HashPhone(e164 string) string {
+	sum := sha256.Sum256([]byte(e164))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,45 @@
+package domain
+
+import "time"
+
+type AgeBucket string
+
+const (
+	AgeLastDay   AgeBucket = "LAST_24H"
+	AgeLastWeek  AgeBucket = "LAST_7D"
+	AgeLastMonth AgeBucket = "LAST_30D"
+	AgeLastYear  AgeBucket = "LAST_365D"
+	AgeOlder     AgeBucket = "OLDER"
+)
+
+// PublicReport is what end users may see of a report: no reporter identity
+// and no exact timestamp.
+type PublicReport struct {
+	Category  RiskCategory `json:"category"`
+	AgeBucket AgeBucket    `json:"age_bucket"`
+	Comment   string       `json:"comment"`
+	Lang      string       `json:"lang,omitempty"`
+}
+
+type PublicReportPage struct {
+	Reports []*PublicReport `json:"reports"`
+	Page    int             `json:"page"`
+	Limit   int             `json:"limit"`
+	HasMore bool            `json:"has_more"`
+}
+
+func AgeBucketFor(createdAt, now time.Time) AgeBucket {
+	age := now.Sub(createdAt)
+	switch {
+	case age < 24*time.Hour:
+		return AgeLastDay
+	case age < 7*24*time.Hour:
+		return AgeLastWeek
+	case age < 30*24*time.Hour:
+		return AgeLastMonth
+	case age < 365*24*time.Hour:
+		return AgeLastYear
+	default:
+		return AgeOlder
+	}
+}
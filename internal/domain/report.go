@@ -26,16 +26,54 @@ const (
 	LevelCritical RiskLevel = "CRITICAL" // Score 61-100
 )
 
+type ModerationStatus string
+
+type ModerationFlag string
+
+const (
+	ModerationPublished ModerationStatus = "PUBLISHED"
+	ModerationPending   ModerationStatus = "PENDING_REVIEW"
+)
+
+const (
+	FlagProfanity ModerationFlag = "PROFANITY"
+	FlagThreat    ModerationFlag = "THREAT"
+)
+
 type Report struct {
 	ID          uuid.UUID `json:"id" db:"id"`
 	PhoneNumber string    `json:"phone_number" db:"phone_number"` // E.164 format
 	CountryCode string    `json:"country_code" db:"country_code"` // ISO 3166-1 alpha-2
 
-	ReporterHash string `json:"reporter_hash" db:"reporter_hash"`
+	ReporterHash       string `json:"reporter_hash" db:"reporter_hash"`
+	ReporterKeyVersion int    `json:"reporter_key_version" db:"reporter_key_version"`
+
+	// Set during a key rotation window: the same reporter hashed with the
+	// previous key, so consensus counting can link old and new reports.
+	ReporterPrevHash       string `json:"reporter_prev_hash,omitempty" db:"reporter_prev_hash"`
+	ReporterPrevKeyVersion int    `json:"reporter_prev_key_version,omitempty" db:"reporter_prev_key_version"`
 
 	Category  RiskCategory `json:"category" db:"category"`
 	Comment   string       `json:"comment,omitempty" db:"comment"`
+	Lang      string       `json:"lang,omitempty" db:"lang"` // ISO 639-1, lower case
 	CreatedAt time.Time    `json:"created_at" db:"created_at"`
+
+	// Empty for reports stored before moderation existed; those comments are
+	// unreviewed and must never be shown publicly.
+	ModerationStatus ModerationStatus `json:"moderation_status,omitempty" db:"moderation_status"`
+}
+
+type ModerationItem struct {
+	ReportID        uuid.UUID        `json:"report_id" db:"report_id"`
+	PhoneNumber     string           `json:"phone_number" db:"phone_number"`
+	CountryCode     string           `json:"country_code" db:"country_code"`
+	ReportCreatedAt time.Time        `json:"report_created_at" db:"report_created_at"`
+	Flags           []ModerationFlag `json:"flags" db:"flags"`
+	EnqueuedAt      time.Time        `json:"enqueued_at" db:"enqueued_at"`
+
+	// TraceContext carries the W3C traceparent of the ingest, so reviewing
+	// the item can join the same trace.
+	TraceContext map[string]string `json:"trace_context,omitempty" db:"trace_context"`
 }
 
 type PhoneScore struct {
@@ -48,7 +86,15 @@ type PhoneScore struct {
 
 	VelocityHitCount int `json:"velocity_hit_count" db:"velocity_hit_count"`
 
+	// TotalReports counts every report folded into the number's aggregate,
+	// auto-blocks included. A rebuild of the aggregate starts it over from
+	// the reports within the decay horizon.
 	TotalReports int `json:"total_reports" db:"total_reports"`
+
+	// Stale marks a score answered from the local blocklist snapshot while
+	// the store was unreachable; SnapshotAt is when that snapshot was taken.
+	Stale      bool      `json:"stale,omitempty" db:"-"`
+	SnapshotAt time.Time `json:"snapshot_at,omitzero" db:"-"`
 }
 
 func NewReport(phone, country, reporterHash string, cat RiskCategory, comment string) *Report {
@@ -0,0 +1,43 @@
+package domain
+
+import "time"
+
+// ScoreExplanation breaks a score down into the terms scoring combined, as
+// of Now. It is computed on demand and never stored.
+type ScoreExplanation struct {
+	PhoneNumber string    `json:"phone_number"`
+	CountryCode string    `json:"country_code"`
+	Now         time.Time `json:"now"`
+
+	Categories []CategoryContribution `json:"categories"`
+	RawScore   float64                `json:"raw_score"`
+
+	// Reporters is the estimated count of distinct human reporters, which
+	// sets ConsensusFactor.
+	Reporters       int     `json:"reporters"`
+	ConsensusFactor float64 `json:"consensus_factor"`
+
+	// AutoBlocks counts AUTO_BLOCK reports of the velocity window; past the
+	// threshold VelocityFloor lifts the score to a minimum.
+	AutoBlocks    int  `json:"auto_blocks"`
+	VelocityFloor bool `json:"velocity_floor"`
+
+	Score     float64   `json:"score"`
+	RiskLevel RiskLevel `json:"risk_level"`
+
+	// Dropped is set when the score is too low to be stored: the number is
+	// removed from the scores and the threat index.
+	Dropped bool `json:"dropped"`
+
+	TotalReports      int       `json:"total_reports"`
+	LastHumanActivity time.Time `json:"last_human_activity"`
+}
+
+// CategoryContribution is what the reports of one category add to the raw
+// score: their decayed count times the category weight.
+type CategoryContribution struct {
+	Category       RiskCategory `json:"category"`
+	DecayedReports float64      `json:"decayed_reports"`
+	Weight         float64      `json:"weight"`
+	Points         float64      `json:"points"`
+}
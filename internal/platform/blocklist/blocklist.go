@@ -0,0 +1,156 @@
+package blocklist
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+	"github.com/rgdevment/spam-registry/internal/platform/logging"
+	"github.com/rgdevment/spam-registry/internal/service"
+)
+
+// file is the on-disk blocklist: every active threat of every country.
+type file struct {
+	GeneratedAt time.Time            `json:"generated_at"`
+	Scores      []*domain.PhoneScore `json:"scores"`
+}
+
+// Export writes the active threats of every region in service.ThreatRegions
+// to path. The file is replaced atomically and synced before the rename, so
+// a reader, even after a crash, never sees a partial export.
+func Export(ctx context.Context, repo service.Repository, path string) (int, error) {
+	export := file{GeneratedAt: time.Now().UTC(), Scores: []*domain.PhoneScore{}}
+	for _, region := range service.ThreatRegions() {
+		threats, err := repo.ListCountryThreats(ctx, region)
+		if err != nil {
+			return 0, fmt.Errorf("blocklist: failed to list threats of %s: %w", region, err)
+		}
+		export.Scores = append(export.Scores, threats...)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return 0, fmt.Errorf("blocklist: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := json.NewEncoder(tmp).Encode(export); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("blocklist: failed to write %s: %w", path, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("blocklist: failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, fmt.Errorf("blocklist: failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, fmt.Errorf("blocklist: %w", err)
+	}
+
+	return len(export.Scores), nil
+}
+
+// Snapshot is the last blocklist export loaded in memory. It answers
+// lookups when the store cannot.
+type Snapshot struct {
+	mu      sync.RWMutex
+	scores  map[string]domain.PhoneScore
+	takenAt time.Time
+	modTime time.Time
+}
+
+func NewSnapshot() *Snapshot {
+	return &Snapshot{}
+}
+
+// Load reads the export at path. It is a no-op when the file has not changed
+// since the previous load.
+func (s *Snapshot) Load(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("blocklist: %w", err)
+	}
+
+	s.mu.RLock()
+	unchanged := info.ModTime().Equal(s.modTime)
+	s.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("blocklist: %w", err)
+	}
+	defer f.Close()
+
+	var export file
+	if err := json.NewDecoder(f).Decode(&export); err != nil {
+		return fmt.Errorf("blocklist: failed to parse %s: %w", path, err)
+	}
+
+	scores := make(map[string]domain.PhoneScore, len(export.Scores))
+	for _, score := range export.Scores {
+		scores[score.PhoneNumber] = *score
+	}
+
+	s.mu.Lock()
+	s.scores = scores
+	s.takenAt = export.GeneratedAt
+	s.modTime = info.ModTime()
+	s.mu.Unlock()
+
+	slog.Info("📥 Blocklist snapshot loaded", "numbers", len(scores), "generated_at", export.GeneratedAt.Format(time.RFC3339))
+	return nil
+}
+
+// Run reloads path every interval until ctx is done. Failures keep the
+// previous snapshot.
+func (s *Snapshot) Run(ctx context.Context, path string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Load(path); err != nil {
+				slog.Warn("⚠️  Blocklist snapshot reload failed", logging.Err(err))
+			}
+		}
+	}
+}
+
+// TakenAt is when the loaded export was generated, or zero before the
+// first load.
+func (s *Snapshot) TakenAt() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.takenAt
+}
+
+// Lookup implements service.ScoreSnapshot. Numbers missing from a loaded
+// snapshot are SAFE: the blocklist holds every known threat.
+func (s *Snapshot) Lookup(phoneNumber string) (*domain.PhoneScore, time.Time, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.scores == nil {
+		return nil, time.Time{}, false
+	}
+
+	score, ok := s.scores[phoneNumber]
+	if !ok {
+		score = domain.PhoneScore{PhoneNumber: phoneNumber, RiskLevel: domain.LevelSafe}
+	}
+	return &score, s.takenAt, true
+}
@@ -0,0 +1,47 @@
+package blocklist_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+	"github.com/rgdevment/spam-registry/internal/platform/blocklist"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportedBlocklistAnswersLookups(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "blocklist.json")
+	repo := memory.NewMemoryRepository()
+
+	threats := map[string]string{"+56911111111": "CL", "+5491122334455": "AR", "+80012345678": "001", "+99912345678": "XX"}
+	for phone, country := range threats {
+		require.NoError(t, repo.UpsertCountryThreat(ctx, &domain.PhoneScore{
+			PhoneNumber: phone, CountryCode: country, Score: 70, RiskLevel: domain.LevelCritical,
+			LastActivity: time.Now().UTC(),
+		}, 3600))
+	}
+
+	count, err := blocklist.Export(ctx, repo, path)
+	require.NoError(t, err)
+	assert.Equal(t, len(threats), count, "threats without a supported region are exported too")
+
+	snap := blocklist.NewSnapshot()
+	_, _, ok := snap.Lookup("+56911111111")
+	assert.False(t, ok, "nothing loaded yet")
+
+	require.NoError(t, snap.Load(path))
+
+	score, takenAt, ok := snap.Lookup("+5491122334455")
+	require.True(t, ok)
+	assert.Equal(t, domain.LevelCritical, score.RiskLevel)
+	assert.WithinDuration(t, time.Now(), takenAt, time.Minute)
+
+	score, _, ok = snap.Lookup("+56922222222")
+	require.True(t, ok)
+	assert.Equal(t, domain.LevelSafe, score.RiskLevel, "numbers outside the blocklist are safe")
+}
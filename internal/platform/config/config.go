@@ -0,0 +1,248 @@
+// Package config loads the settings shared by every binary. Each setting has
+// one name, its environment variable, and is resolved from, in order of
+// precedence: a flag, the environment (including .env), a config file, and
+// the built-in default.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"github.com/rgdevment/spam-registry/internal/platform/logging"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/backend"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/breaker"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/cache"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/scylla"
+	"github.com/rgdevment/spam-registry/internal/platform/tracing"
+	"github.com/rgdevment/spam-registry/internal/service"
+)
+
+// Binaries, which decide the defaults and the required settings.
+const (
+	API    = "api"
+	Worker = "worker"
+	CLI    = "gsrctl"
+)
+
+type Config struct {
+	Binary string
+
+	Storage backend.Config
+	Logging logging.Config
+	Tracing tracing.Config
+
+	// SaltSecret is the legacy single reporter salt; SaltKeyring, when set,
+	// takes over with versioned secrets.
+	SaltSecret  string
+	SaltKeyring string
+
+	CommentMasterKeyFile string
+
+	API    APIConfig
+	Worker WorkerConfig
+	CLI    CLIConfig
+
+	// sources records where each setting came from, for PrintConfig.
+	sources map[string]string
+}
+
+type APIConfig struct {
+	MasterKey   string
+	Addr        string
+	MetricsAddr string
+
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+	DrainDelay      time.Duration
+
+	SnapshotPath   string
+	SnapshotMaxAge time.Duration
+	SpoolDir       string
+}
+
+type WorkerConfig struct {
+	MetricsAddr string
+}
+
+type CLIConfig struct {
+	// APIURL, when set, makes gsrctl use the HTTP API instead of the store
+	// for the commands the API can serve.
+	APIURL string
+}
+
+// Default is the configuration of binary before any source is applied.
+func Default(binary string) Config {
+	return Config{
+		Binary: binary,
+		Storage: backend.Config{
+			Kind:       backend.KindScylla,
+			Scylla:     scyllaDefaults(),
+			Breaker:    breaker.DefaultConfig(),
+			SQLitePath: "gsr.db",
+			ScoreCache: cache.DefaultConfig(),
+		},
+		Logging: logging.DefaultConfig(),
+		Tracing: tracing.Config{Exporter: tracing.ExporterNone, ServiceName: "gsr-" + binary},
+		API: APIConfig{
+			Addr:            ":8080",
+			MetricsAddr:     ":9090",
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    15 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 25 * time.Second,
+			DrainDelay:      5 * time.Second,
+			SnapshotMaxAge:  time.Hour,
+		},
+		Worker: WorkerConfig{
+			MetricsAddr: ":9091",
+		},
+	}
+}
+
+func scyllaDefaults() scylla.Config {
+	cfg := scylla.DefaultConfig()
+	cfg.Keyspace = "gsr"
+	return cfg
+}
+
+// Load resolves the configuration of binary. It registers a flag per
+// non-secret setting on fs, plus -config and -print-config, and parses args
+// with it; the caller reads its own flags from fs afterwards. With
+// -print-config the effective configuration is written to stdout and the
+// process exits.
+func Load(binary string, fs *flag.FlagSet, args []string) (Config, error) {
+	cfg := Default(binary)
+	cfg.sources = make(map[string]string)
+	settings := cfg.settings()
+
+	flagValues := make(map[string]string)
+	for _, s := range settings {
+		if s.secret {
+			continue
+		}
+		key := s.key
+		fs.Func(s.flagName(), s.help, func(v string) error {
+			flagValues[key] = v
+			return nil
+		})
+	}
+	configFile := fs.String("config", os.Getenv("GSR_CONFIG_FILE"), "KEY=VALUE file with settings, below the environment in precedence")
+	printConfig := fs.Bool("print-config", false, "Print the effective configuration, secrets redacted, and exit")
+
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	// .env only fills variables the environment does not set.
+	_ = godotenv.Load()
+
+	var fileValues map[string]string
+	if *configFile != "" {
+		var err error
+		if fileValues, err = godotenv.Read(*configFile); err != nil {
+			return cfg, fmt.Errorf("config: %w", err)
+		}
+	}
+
+	for _, s := range settings {
+		value, source := "", ""
+		if v, ok := fileValues[s.key]; ok {
+			value, source = v, "file"
+		}
+		if v, ok := os.LookupEnv(s.key); ok && v != "" {
+			value, source = v, "env"
+		}
+		if v, ok := flagValues[s.key]; ok {
+			value, source = v, "flag"
+		}
+		if source == "" {
+			continue
+		}
+		if err := s.set(value); err != nil {
+			return cfg, fmt.Errorf("config: %s (from %s): %w", s.key, source, err)
+		}
+		cfg.sources[s.key] = source
+	}
+
+	if *printConfig {
+		cfg.PrintConfig(os.Stdout)
+		os.Exit(0)
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// Validate checks the settings binary cannot start without.
+func (c Config) Validate() error {
+	var errs []error
+
+	if c.Binary == API || c.Binary == Worker {
+		if _, err := c.Keys(); err != nil {
+			errs = append(errs, fmt.Errorf("APP_SALT_SECRET or APP_SALT_KEYRING: %w", err))
+		}
+	}
+	if c.Binary == API && c.API.MasterKey == "" {
+		errs = append(errs, errors.New("API_MASTER_KEY is required"))
+	}
+
+	switch c.Storage.Kind {
+	case backend.KindScylla, backend.KindMemory, backend.KindSQLite:
+	default:
+		errs = append(errs, fmt.Errorf("STORAGE: unknown backend %q (use scylla, memory or sqlite)", c.Storage.Kind))
+	}
+	if c.Storage.Kind == backend.KindScylla && len(c.Storage.Scylla.Hosts) == 0 {
+		errs = append(errs, errors.New("SCYLLA_HOST: at least one host is required"))
+	}
+	if err := c.Logging.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Keys builds the reporter keyring.
+func (c Config) Keys() (*service.Keyring, error) {
+	return service.ParseKeyring(c.SaltKeyring, c.SaltSecret)
+}
+
+// Server returns a server of h on addr with the API's timeouts. The public
+// listener and the internal metrics and probe listeners all use it.
+func (c APIConfig) Server(addr string, h http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           h,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       c.ReadTimeout,
+		WriteTimeout:      c.WriteTimeout,
+		IdleTimeout:       c.IdleTimeout,
+	}
+}
+
+// PrintConfig writes every setting as KEY=VALUE with where it came from.
+// Secrets that are set print as <redacted>.
+func (c *Config) PrintConfig(w io.Writer) {
+	settings := c.settings()
+	sort.Slice(settings, func(i, j int) bool { return settings[i].key < settings[j].key })
+
+	for _, s := range settings {
+		value := s.get()
+		if s.secret && value != "" {
+			value = "<redacted>"
+		}
+		source := c.sources[s.key]
+		if source == "" {
+			source = "default"
+		}
+		fmt.Fprintf(w, "%s=%s\t# %s\n", s.key, value, source)
+	}
+}
@@ -0,0 +1,105 @@
+package config_test
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rgdevment/spam-registry/internal/platform/config"
+)
+
+func load(t *testing.T, binary string, args ...string) (config.Config, error) {
+	t.Helper()
+	fs := flag.NewFlagSet(binary, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return config.Load(binary, fs, args)
+}
+
+func TestSourcesApplyInOrder(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "gsr.conf")
+	require.NoError(t, os.WriteFile(file, []byte(
+		"SCYLLA_KEYSPACE=from_file\nSCYLLA_TIMEOUT=7s\nHTTP_PORT=:7000\nAPP_SALT_SECRET=file_salt\n"), 0o600))
+
+	t.Setenv("SCYLLA_TIMEOUT", "9s")
+	t.Setenv("HTTP_PORT", ":8000")
+	t.Setenv("API_MASTER_KEY", "master")
+
+	cfg, err := load(t, config.API, "-config", file, "-http-port", ":9000", "-scylla-host", "a, b")
+	require.NoError(t, err)
+
+	assert.Equal(t, "from_file", cfg.Storage.Scylla.Keyspace, "file over default")
+	assert.Equal(t, 9*time.Second, cfg.Storage.Scylla.Timeout, "env over file")
+	assert.Equal(t, ":9000", cfg.API.Addr, "flag over env")
+	assert.Equal(t, []string{"a", "b"}, cfg.Storage.Scylla.Hosts)
+	assert.Equal(t, 25*time.Second, cfg.API.ShutdownTimeout, "untouched settings keep their default")
+	assert.Equal(t, "gsr-api", cfg.Tracing.ServiceName)
+
+	keys, err := cfg.Keys()
+	require.NoError(t, err)
+	assert.Equal(t, 1, keys.ActiveVersion())
+}
+
+func TestWorkerListenerTakesTheHTTPTimeouts(t *testing.T) {
+	t.Setenv("APP_SALT_SECRET", "salt")
+	t.Setenv("HTTP_WRITE_TIMEOUT", "3s")
+
+	cfg, err := load(t, config.Worker)
+	require.NoError(t, err)
+
+	srv := cfg.API.Server(cfg.Worker.MetricsAddr, nil)
+	assert.Equal(t, ":9091", srv.Addr)
+	assert.Equal(t, 5*time.Second, srv.ReadHeaderTimeout)
+	assert.Equal(t, 10*time.Second, srv.ReadTimeout)
+	assert.Equal(t, 3*time.Second, srv.WriteTimeout)
+	assert.Equal(t, 60*time.Second, srv.IdleTimeout)
+}
+
+func TestRequiredSettingsAreValidated(t *testing.T) {
+	t.Setenv("APP_SALT_SECRET", "")
+	t.Setenv("APP_SALT_KEYRING", "")
+	t.Setenv("API_MASTER_KEY", "")
+
+	_, err := load(t, config.API)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "APP_SALT_SECRET")
+	assert.ErrorContains(t, err, "API_MASTER_KEY")
+
+	_, err = load(t, config.Worker)
+	assert.ErrorContains(t, err, "APP_SALT_SECRET")
+
+	_, err = load(t, config.CLI)
+	assert.NoError(t, err, "gsrctl only needs the salt for commands that hash")
+
+	t.Setenv("SCYLLA_TIMEOUT", "soon")
+	_, err = load(t, config.CLI)
+	assert.ErrorContains(t, err, "SCYLLA_TIMEOUT (from env)")
+}
+
+func TestPrintConfigRedactsSecrets(t *testing.T) {
+	t.Setenv("APP_SALT_SECRET", "s3cret-salt")
+	t.Setenv("SCYLLA_PASSWORD", "s3cret-pass")
+
+	cfg, err := load(t, config.Worker, "-log-level", "debug")
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	cfg.PrintConfig(&out)
+
+	assert.NotContains(t, out.String(), "s3cret")
+	assert.Contains(t, out.String(), "APP_SALT_SECRET=<redacted>\t# env")
+	assert.Contains(t, out.String(), "API_MASTER_KEY=\t# default")
+	assert.Contains(t, out.String(), "LOG_LEVEL=DEBUG\t# flag")
+	assert.Contains(t, out.String(), "SCYLLA_KEYSPACE=gsr\t# default")
+}
+
+func TestSecretsHaveNoFlag(t *testing.T) {
+	_, err := load(t, config.CLI, "-api-master-key", "x")
+	assert.Error(t, err, "secrets would show up in the process list")
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+
+	"github.com/rgdevment/spam-registry/internal/platform/storage/scylla"
+)
+
+// setting binds one environment variable to a field of Config.
+type setting struct {
+	key    string
+	help   string
+	secret bool
+	set    func(string) error
+	get    func() string
+}
+
+// flagName turns SCYLLA_HOST into scylla-host.
+func (s setting) flagName() string {
+	return strings.ReplaceAll(strings.ToLower(s.key), "_", "-")
+}
+
+func (c *Config) settings() []setting {
+	sc := &c.Storage.Scylla
+	return []setting{
+		str("STORAGE", "Storage backend: scylla, memory or sqlite", &c.Storage.Kind),
+		list("SCYLLA_HOST", "Comma separated Scylla seed hosts", &sc.Hosts),
+		str("SCYLLA_KEYSPACE", "Scylla keyspace", &sc.Keyspace),
+		str("SCYLLA_USERNAME", "Scylla user", &sc.Username),
+		secret(str("SCYLLA_PASSWORD", "Scylla password", &sc.Password)),
+		str("SCYLLA_TLS_CA_FILE", "CA bundle for Scylla TLS", &sc.TLSCAFile),
+		str("SCYLLA_TLS_CERT_FILE", "Client certificate for Scylla TLS", &sc.TLSCertFile),
+		str("SCYLLA_TLS_KEY_FILE", "Client key for Scylla TLS", &sc.TLSKeyFile),
+		boolean("SCYLLA_TLS_SKIP_HOST_VERIFY", "Skip Scylla TLS host name verification", &sc.TLSSkipHostVerify),
+		str("SCYLLA_LOCAL_DC", "Pin the driver to this datacenter", &sc.LocalDC),
+		replication("SCYLLA_REPLICATION", "Keyspace replication as dc:factor pairs", &sc.Replication),
+		consistency("SCYLLA_LOOKUP_CONSISTENCY", "Consistency of public lookups", &sc.LookupConsistency),
+		consistency("SCYLLA_WRITE_CONSISTENCY", "Consistency of writes", &sc.WriteConsistency),
+		duration("SCYLLA_TIMEOUT", "Scylla query timeout", &sc.Timeout),
+		duration("SCYLLA_CONNECT_TIMEOUT", "Scylla connect timeout", &sc.ConnectTimeout),
+		integer("SCYLLA_RETRY_ATTEMPTS", "Retries of a failed Scylla query", &sc.RetryAttempts),
+		integer("SCYLLA_SPECULATIVE_ATTEMPTS", "Extra coordinators tried for slow lookups", &sc.SpeculativeAttempts),
+		duration("SCYLLA_SPECULATIVE_DELAY", "Delay before a speculative lookup", &sc.SpeculativeDelay),
+		integer("SCYLLA_BREAKER_THRESHOLD", "Consecutive failures that open the breaker", &c.Storage.Breaker.Threshold),
+		duration("SCYLLA_BREAKER_COOLDOWN", "Time the breaker stays open", &c.Storage.Breaker.Cooldown),
+		str("MEMORY_SNAPSHOT_PATH", "Snapshot file of the memory backend", &c.Storage.MemorySnapshotPath),
+		str("SQLITE_PATH", "Database file of the sqlite backend", &c.Storage.SQLitePath),
+		integer("SCORE_CACHE_SIZE", "Score cache entries in the API, 0 disables it", &c.Storage.ScoreCache.Size),
+		duration("SCORE_CACHE_TTL", "Score cache TTL", &c.Storage.ScoreCache.TTL),
+		duration("SCORE_CACHE_NEGATIVE_TTL", "Score cache TTL of unknown numbers", &c.Storage.ScoreCache.NegativeTTL),
+
+		secret(str("APP_SALT_SECRET", "Legacy reporter salt, loaded as key version 1", &c.SaltSecret)),
+		secret(str("APP_SALT_KEYRING", "Reporter keys as version:secret pairs", &c.SaltKeyring)),
+		str("COMMENT_MASTER_KEY_FILE", "Master key file for comment encryption", &c.CommentMasterKeyFile),
+
+		secret(str("API_MASTER_KEY", "API key clients must send", &c.API.MasterKey)),
+		str("HTTP_PORT", "API listen address", &c.API.Addr),
+		str("METRICS_ADDR", "API metrics listen address", &c.API.MetricsAddr),
+		duration("HTTP_READ_TIMEOUT", "Time to read a whole request", &c.API.ReadTimeout),
+		duration("HTTP_WRITE_TIMEOUT", "Time to write a response", &c.API.WriteTimeout),
+		duration("HTTP_IDLE_TIMEOUT", "Keep-alive idle timeout", &c.API.IdleTimeout),
+		duration("SHUTDOWN_TIMEOUT", "Drain window on SIGTERM", &c.API.ShutdownTimeout),
+		duration("SHUTDOWN_DRAIN_DELAY", "Time /readyz fails before the listener closes on SIGTERM", &c.API.DrainDelay),
+		str("BLOCKLIST_SNAPSHOT_PATH", "Blocklist served while Scylla is down", &c.API.SnapshotPath),
+		duration("READY_SNAPSHOT_MAX_AGE", "Snapshot age /readyz reports as degraded", &c.API.SnapshotMaxAge),
+		str("SPOOL_DIR", "Directory of the report spool", &c.API.SpoolDir),
+
+		str("WORKER_METRICS_ADDR", "Worker daemon metrics and probes address", &c.Worker.MetricsAddr),
+
+		str("GSR_API_URL", "Base URL of the API gsrctl talks to instead of the store", &c.CLI.APIURL),
+
+		level("LOG_LEVEL", "Log level: debug, info, warn or error", &c.Logging.Level),
+		lower("LOG_FORMAT", "Log format: text or json", &c.Logging.Format),
+		lower("LOG_PHONE_POLICY", "How phones are logged: mask or hash", &c.Logging.PhonePolicy),
+		secret(str("LOG_PHONE_HASH_KEY", "HMAC key of the hash phone policy", &c.Logging.PhoneHashKey)),
+
+		str("OTEL_TRACES_EXPORTER", "Trace exporter: none, otlp or stdout", &c.Tracing.Exporter),
+		str("OTEL_SERVICE_NAME", "Service name on traces", &c.Tracing.ServiceName),
+	}
+}
+
+func secret(s setting) setting {
+	s.secret = true
+	return s
+}
+
+func str(key, help string, p *string) setting {
+	return setting{key: key, help: help,
+		set: func(v string) error { *p = v; return nil },
+		get: func() string { return *p },
+	}
+}
+
+func lower(key, help string, p *string) setting {
+	return setting{key: key, help: help,
+		set: func(v string) error { *p = strings.ToLower(v); return nil },
+		get: func() string { return *p },
+	}
+}
+
+func list(key, help string, p *[]string) setting {
+	return setting{key: key, help: help,
+		set: func(v string) error {
+			var items []string
+			for _, item := range strings.Split(v, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			*p = items
+			return nil
+		},
+		get: func() string { return strings.Join(*p, ",") },
+	}
+}
+
+func integer(key, help string, p *int) setting {
+	return setting{key: key, help: help,
+		set: func(v string) (err error) { *p, err = strconv.Atoi(v); return err },
+		get: func() string { return strconv.Itoa(*p) },
+	}
+}
+
+func boolean(key, help string, p *bool) setting {
+	return setting{key: key, help: help,
+		set: func(v string) (err error) { *p, err = strconv.ParseBool(v); return err },
+		get: func() string { return strconv.FormatBool(*p) },
+	}
+}
+
+func duration(key, help string, p *time.Duration) setting {
+	return setting{key: key, help: help,
+		set: func(v string) (err error) { *p, err = time.ParseDuration(v); return err },
+		get: func() string { return p.String() },
+	}
+}
+
+func level(key, help string, p *slog.Level) setting {
+	return setting{key: key, help: help,
+		set: func(v string) error { return p.UnmarshalText([]byte(v)) },
+		get: func() string { return p.String() },
+	}
+}
+
+func consistency(key, help string, p *gocql.Consistency) setting {
+	return setting{key: key, help: help,
+		set: func(v string) (err error) { *p, err = gocql.ParseConsistencyWrapper(v); return err },
+		get: func() string { return p.String() },
+	}
+}
+
+func replication(key, help string, p *scylla.Replication) setting {
+	return setting{key: key, help: help,
+		set: func(v string) (err error) { *p, err = scylla.ParseReplication(v); return err },
+		get: func() string {
+			pairs := make([]string, 0, len(*p))
+			for dc, rf := range *p {
+				pairs = append(pairs, fmt.Sprintf("%s:%d", dc, rf))
+			}
+			sort.Strings(pairs)
+			return strings.Join(pairs, ",")
+		},
+	}
+}
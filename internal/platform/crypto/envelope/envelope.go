@@ -0,0 +1,204 @@
+package envelope
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	sealedPrefix = "enc:v1:"
+	dataKeySize  = 32
+	cacheTTL     = 10 * time.Minute
+)
+
+var (
+	ErrDataKeyNotFound = errors.New("envelope: data key not found")
+	ErrDataKeyShredded = errors.New("envelope: data key was shredded")
+)
+
+// KeyProvider wraps data keys with a master key. FileKeyProvider covers local
+// deployments; a KMS client only needs to implement these two calls.
+type KeyProvider interface {
+	Wrap(ctx context.Context, dataKey []byte) ([]byte, error)
+	Unwrap(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// KeyStore persists wrapped data keys. CreateDataKey must be insert-if-absent
+// and return the stored key, so concurrent writers agree on one key per ID.
+type KeyStore interface {
+	GetDataKey(ctx context.Context, keyID string) ([]byte, error)
+	CreateDataKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+	ShredDataKey(ctx context.Context, keyID string) error
+}
+
+type cachedKey struct {
+	key      []byte
+	loadedAt time.Time
+}
+
+// Encrypter seals strings with a data key per calendar month, so a whole
+// month can be made unreadable by shredding a single key.
+type Encrypter struct {
+	provider KeyProvider
+	store    KeyStore
+
+	mu    sync.Mutex
+	cache map[string]cachedKey
+}
+
+func NewEncrypter(provider KeyProvider, store KeyStore) *Encrypter {
+	return &Encrypter{
+		provider: provider,
+		store:    store,
+		cache:    make(map[string]cachedKey),
+	}
+}
+
+func MonthKeyID(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, sealedPrefix)
+}
+
+// Seal encrypts plaintext under the data key for the month of at. aad binds
+// the ciphertext to its row so it cannot be moved to another record.
+func (e *Encrypter) Seal(ctx context.Context, at time.Time, plaintext string, aad []byte) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	keyID := MonthKeyID(at)
+	key, err := e.dataKey(ctx, keyID, true)
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("envelope: failed to generate nonce: %w", err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), aad)
+	return sealedPrefix + keyID + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value produced by Seal. Values without the sealed prefix
+// predate encryption and are returned unchanged.
+func (e *Encrypter) Open(ctx context.Context, value string, aad []byte) (string, error) {
+	if !IsSealed(value) {
+		return value, nil
+	}
+
+	keyID, payload, ok := strings.Cut(strings.TrimPrefix(value, sealedPrefix), ":")
+	if !ok {
+		return "", errors.New("envelope: malformed sealed value")
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", fmt.Errorf("envelope: malformed sealed value: %w", err)
+	}
+
+	key, err := e.dataKey(ctx, keyID, false)
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("envelope: sealed value too short")
+	}
+
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], aad)
+	if err != nil {
+		return "", fmt.Errorf("envelope: failed to decrypt: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// Shred destroys the data key of a month. Other processes may keep a cached
+// copy for up to cacheTTL.
+func (e *Encrypter) Shred(ctx context.Context, month time.Time) error {
+	keyID := MonthKeyID(month)
+
+	if err := e.store.ShredDataKey(ctx, keyID); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	delete(e.cache, keyID)
+	e.mu.Unlock()
+
+	return nil
+}
+
+func (e *Encrypter) dataKey(ctx context.Context, keyID string, create bool) ([]byte, error) {
+	e.mu.Lock()
+	cached, ok := e.cache[keyID]
+	e.mu.Unlock()
+
+	if ok && time.Since(cached.loadedAt) < cacheTTL {
+		return cached.key, nil
+	}
+
+	wrapped, err := e.store.GetDataKey(ctx, keyID)
+	if errors.Is(err, ErrDataKeyNotFound) && create {
+		wrapped, err = e.createDataKey(ctx, keyID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := e.provider.Unwrap(ctx, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: failed to unwrap data key %s: %w", keyID, err)
+	}
+
+	e.mu.Lock()
+	e.cache[keyID] = cachedKey{key: key, loadedAt: time.Now()}
+	e.mu.Unlock()
+
+	return key, nil
+}
+
+func (e *Encrypter) createDataKey(ctx context.Context, keyID string) ([]byte, error) {
+	key := make([]byte, dataKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("envelope: failed to generate data key: %w", err)
+	}
+
+	wrapped, err := e.provider.Wrap(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: failed to wrap data key %s: %w", keyID, err)
+	}
+
+	return e.store.CreateDataKey(ctx, keyID, wrapped)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: invalid key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
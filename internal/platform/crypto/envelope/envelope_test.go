@@ -0,0 +1,89 @@
+package envelope_test
+
+import (
+	"context"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rgdevment/spam-registry/internal/platform/crypto/envelope"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memKeyStore struct {
+	mu       sync.Mutex
+	keys     map[string][]byte
+	shredded map[string]bool
+}
+
+func (m *memKeyStore) GetDataKey(ctx context.Context, keyID string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.shredded[keyID] {
+		return nil, envelope.ErrDataKeyShredded
+	}
+	if k, ok := m.keys[keyID]; ok {
+		return k, nil
+	}
+	return nil, envelope.ErrDataKeyNotFound
+}
+
+func (m *memKeyStore) CreateDataKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.shredded[keyID] {
+		return nil, envelope.ErrDataKeyShredded
+	}
+	if k, ok := m.keys[keyID]; ok {
+		return k, nil
+	}
+	m.keys[keyID] = wrapped
+	return wrapped, nil
+}
+
+func (m *memKeyStore) ShredDataKey(ctx context.Context, keyID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.keys, keyID)
+	m.shredded[keyID] = true
+	return nil
+}
+
+func TestSealOpenAndShred(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "master.key")
+	require.NoError(t, os.WriteFile(keyFile, []byte(hex.EncodeToString(make([]byte, 32))), 0o600))
+
+	provider, err := envelope.NewFileKeyProvider(keyFile)
+	require.NoError(t, err)
+
+	store := &memKeyStore{keys: map[string][]byte{}, shredded: map[string]bool{}}
+	enc := envelope.NewEncrypter(provider, store)
+	ctx := context.Background()
+	march := time.Date(2025, 3, 14, 10, 0, 0, 0, time.UTC)
+	aad := []byte("+56911111111")
+
+	sealed, err := enc.Seal(ctx, march, "me pidió mi RUT y clave", aad)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(sealed, "enc:v1:2025-03:"))
+	assert.NotContains(t, sealed, "RUT")
+
+	plain, err := enc.Open(ctx, sealed, aad)
+	require.NoError(t, err)
+	assert.Equal(t, "me pidió mi RUT y clave", plain)
+
+	_, err = enc.Open(ctx, sealed, []byte("+56922222222"))
+	assert.Error(t, err, "ciphertext must be bound to its row")
+
+	legacy, err := enc.Open(ctx, "plaintext from before encryption", aad)
+	require.NoError(t, err)
+	assert.Equal(t, "plaintext from before encryption", legacy)
+
+	require.NoError(t, enc.Shred(ctx, march))
+	_, err = enc.Open(ctx, sealed, aad)
+	assert.ErrorIs(t, err, envelope.ErrDataKeyShredded)
+}
@@ -0,0 +1,64 @@
+package envelope
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type fileKeyProvider struct {
+	masterKey []byte
+}
+
+// NewFileKeyProvider loads a 32-byte AES master key, hex or base64 encoded,
+// from a local keyfile.
+func NewFileKeyProvider(path string) (KeyProvider, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: failed to read master keyfile: %w", err)
+	}
+
+	encoded := strings.TrimSpace(string(raw))
+
+	key, err := hex.DecodeString(encoded)
+	if err != nil {
+		key, err = base64.StdEncoding.DecodeString(encoded)
+	}
+	if err != nil || len(key) != dataKeySize {
+		return nil, errors.New("envelope: master keyfile must contain a 32-byte key in hex or base64")
+	}
+
+	return &fileKeyProvider{masterKey: key}, nil
+}
+
+func (p *fileKeyProvider) Wrap(ctx context.Context, dataKey []byte) ([]byte, error) {
+	aead, err := newAEAD(p.masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, dataKey, nil), nil
+}
+
+func (p *fileKeyProvider) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	aead, err := newAEAD(p.masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(wrapped) < aead.NonceSize() {
+		return nil, errors.New("envelope: wrapped key too short")
+	}
+
+	return aead.Open(nil, wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():], nil)
+}
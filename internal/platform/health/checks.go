@@ -0,0 +1,40 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Backlog fails when more than max bytes wait in a local backlog such as
+// the report spool.
+func Backlog(size func() (int64, error), max int64) Check {
+	return func(context.Context) (string, error) {
+		n, err := size()
+		if err != nil {
+			return "", err
+		}
+		detail := fmt.Sprintf("%d bytes", n)
+		if n > max {
+			return detail, fmt.Errorf("%d bytes waiting, over the %d limit", n, max)
+		}
+		return detail, nil
+	}
+}
+
+// Freshness fails when the time returned by at is zero or older than maxAge.
+// what names the thing in the error, e.g. "snapshot".
+func Freshness(what string, at func() time.Time, maxAge time.Duration) Check {
+	return func(context.Context) (string, error) {
+		t := at()
+		if t.IsZero() {
+			return "", fmt.Errorf("no %s yet", what)
+		}
+		age := time.Since(t).Round(time.Second)
+		detail := fmt.Sprintf("%s old", age)
+		if age > maxAge {
+			return detail, fmt.Errorf("%s is %s old, over %s", what, age, maxAge)
+		}
+		return detail, nil
+	}
+}
@@ -0,0 +1,177 @@
+// Package health serves the liveness and readiness probes of the binaries.
+// Liveness only says the process answers; readiness runs the registered
+// dependency checks and reports each one.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+)
+
+// Check probes one dependency. The returned detail is reported when it
+// passes, e.g. the schema version or a backlog size.
+type Check func(ctx context.Context) (detail string, err error)
+
+type check struct {
+	name     string
+	fn       Check
+	critical bool
+
+	// fallback, when set, makes a critical failure only degrade the
+	// instance while it passes.
+	fallback Check
+}
+
+// Checker runs readiness checks. A failing critical check makes the instance
+// unready; any other failure only marks it degraded.
+type Checker struct {
+	timeout  time.Duration
+	checks   []check
+	draining atomic.Bool
+}
+
+type Option func(*Checker)
+
+// WithTimeout bounds each check. Defaults to 2s.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Checker) {
+		c.timeout = d
+	}
+}
+
+func New(opts ...Option) *Checker {
+	c := &Checker{timeout: 2 * time.Second}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Critical registers a check the instance cannot serve without.
+func (c *Checker) Critical(name string, fn Check) {
+	c.checks = append(c.checks, check{name: name, fn: fn, critical: true})
+}
+
+// CriticalUnless registers a check the instance cannot serve without, unless
+// fallback passes: something else, such as a fresh snapshot, answers for the
+// dependency in the meantime.
+func (c *Checker) CriticalUnless(name string, fn, fallback Check) {
+	c.checks = append(c.checks, check{name: name, fn: fn, critical: true, fallback: fallback})
+}
+
+// Optional registers a check whose failure leaves the instance serving,
+// with less margin.
+func (c *Checker) Optional(name string, fn Check) {
+	c.checks = append(c.checks, check{name: name, fn: fn})
+}
+
+type ComponentReport struct {
+	Status   string  `json:"status"`
+	Critical bool    `json:"critical"`
+	Detail   string  `json:"detail,omitempty"`
+	Error    string  `json:"error,omitempty"`
+	Latency  float64 `json:"latency_ms"`
+}
+
+type Report struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentReport `json:"components"`
+}
+
+// Drain makes the instance unready for good, whatever the checks say, so
+// load balancers take it out of rotation before it stops serving.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Run executes every check concurrently.
+func (c *Checker) Run(ctx context.Context) Report {
+	if c.draining.Load() {
+		return Report{Status: StatusDown, Components: map[string]ComponentReport{
+			"shutdown": {Status: StatusDown, Critical: true, Error: "shutting down"},
+		}}
+	}
+
+	report := Report{Status: StatusOK, Components: make(map[string]ComponentReport, len(c.checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, chk := range c.checks {
+		wg.Go(func() {
+			ctx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			start := time.Now()
+			detail, err := chk.fn(ctx)
+			critical := chk.critical
+			if err != nil && chk.fallback != nil {
+				if _, fbErr := chk.fallback(ctx); fbErr == nil {
+					critical = false
+				}
+			}
+			component := ComponentReport{
+				Status:   StatusOK,
+				Critical: critical,
+				Detail:   detail,
+				Latency:  float64(time.Since(start).Microseconds()) / 1000,
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				component.Error = err.Error()
+				component.Status = StatusDegraded
+				if critical {
+					component.Status = StatusDown
+				}
+				report.Status = worse(report.Status, component.Status)
+			}
+			report.Components[chk.name] = component
+		})
+	}
+	wg.Wait()
+
+	return report
+}
+
+func worse(a, b string) string {
+	rank := map[string]int{StatusOK: 0, StatusDegraded: 1, StatusDown: 2}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
+
+// Liveness answers 200 while the process can serve HTTP at all. It checks
+// no dependency, so a store outage never gets the instance restarted.
+func Liveness(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": StatusOK})
+}
+
+// Readiness answers 503 while a critical check fails, 200 otherwise.
+func (c *Checker) Readiness(w http.ResponseWriter, r *http.Request) {
+	report := c.Run(r.Context())
+
+	code := http.StatusOK
+	if report.Status == StatusDown {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, report)
+}
+
+func writeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,113 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rgdevment/spam-registry/internal/platform/health"
+)
+
+func ok(detail string) health.Check {
+	return func(context.Context) (string, error) { return detail, nil }
+}
+
+func failing(msg string) health.Check {
+	return func(context.Context) (string, error) { return "", errors.New(msg) }
+}
+
+func probe(t *testing.T, c *health.Checker) (int, health.Report) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	c.Readiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var report health.Report
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&report))
+	return rec.Code, report
+}
+
+func TestReadinessReportsEveryComponent(t *testing.T) {
+	c := health.New()
+	c.Critical("storage", ok("scylla"))
+	c.Optional("blocklist_snapshot", failing("no snapshot yet"))
+
+	code, report := probe(t, c)
+	assert.Equal(t, http.StatusOK, code, "an optional failure keeps the instance in rotation")
+	assert.Equal(t, health.StatusDegraded, report.Status)
+	assert.Equal(t, health.StatusOK, report.Components["storage"].Status)
+	assert.Equal(t, "scylla", report.Components["storage"].Detail)
+	assert.Equal(t, "no snapshot yet", report.Components["blocklist_snapshot"].Error)
+
+	c.Critical("schema", failing("schema outdated"))
+	code, report = probe(t, c)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health.StatusDown, report.Status)
+	assert.Equal(t, health.StatusDown, report.Components["schema"].Status)
+}
+
+func TestFallbackOnlyCoversACriticalCheckWhilePassing(t *testing.T) {
+	fresh := true
+	c := health.New()
+	c.CriticalUnless("storage", failing("no hosts available"), func(context.Context) (string, error) {
+		if fresh {
+			return "", nil
+		}
+		return "", errors.New("snapshot is stale")
+	})
+
+	code, report := probe(t, c)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, health.StatusDegraded, report.Components["storage"].Status)
+	assert.False(t, report.Components["storage"].Critical)
+
+	fresh = false
+	code, report = probe(t, c)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health.StatusDown, report.Components["storage"].Status)
+}
+
+func TestDrainingInstanceIsUnready(t *testing.T) {
+	c := health.New()
+	c.Critical("storage", ok("scylla"))
+
+	c.Drain()
+	code, report := probe(t, c)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health.StatusDown, report.Status)
+	assert.Equal(t, "shutting down", report.Components["shutdown"].Error)
+}
+
+func TestChecksAreBoundedByTimeout(t *testing.T) {
+	c := health.New(health.WithTimeout(10 * time.Millisecond))
+	c.Critical("storage", func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+
+	code, _ := probe(t, c)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+}
+
+func TestFreshnessAndBacklog(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := health.Freshness("snapshot", func() time.Time { return time.Time{} }, time.Hour)(ctx)
+	assert.EqualError(t, err, "no snapshot yet")
+	_, err = health.Freshness("snapshot", func() time.Time { return time.Now().Add(-2 * time.Hour) }, time.Hour)(ctx)
+	assert.Error(t, err)
+	_, err = health.Freshness("snapshot", time.Now, time.Hour)(ctx)
+	assert.NoError(t, err)
+
+	detail, err := health.Backlog(func() (int64, error) { return 10, nil }, 100)(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "10 bytes", detail)
+	_, err = health.Backlog(func() (int64, error) { return 200, nil }, 100)(ctx)
+	assert.Error(t, err)
+}
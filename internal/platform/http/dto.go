@@ -2,13 +2,23 @@ package http
 
 import (
 	"errors"
+	"slices"
 	"strings"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
 )
 
+// ReportCategories are the categories clients may file a report under.
+// AUTO_BLOCK is not accepted from clients: it only feeds the velocity signal.
+var ReportCategories = []domain.RiskCategory{
+	domain.RiskSpam, domain.RiskFraud, domain.RiskPhishing, domain.RiskDebt, domain.RiskSales,
+}
+
 type CreateReportRequest struct {
 	PhoneNumber string `json:"phone_number"`
 	Category    string `json:"category"`
 	Comment     string `json:"comment"`
+	Lang        string `json:"lang,omitempty"`
 }
 
 func (r *CreateReportRequest) Validate() error {
@@ -16,14 +26,41 @@ func (r *CreateReportRequest) Validate() error {
 		return errors.New("phone_number is too short")
 	}
 
-	validCategories := map[string]bool{
-		"SPAM": true, "FRAUD": true, "PHISHING": true,
-		"DEBT_COLLECTION": true, "SALES": true,
+	if !slices.Contains(ReportCategories, domain.RiskCategory(strings.ToUpper(r.Category))) {
+		return errors.New("invalid category")
 	}
 
-	if !validCategories[strings.ToUpper(r.Category)] {
-		return errors.New("invalid category")
+	if r.Lang != "" && !isLangCode(r.Lang) {
+		return errors.New("lang must be a two-letter ISO 639-1 code")
 	}
 
 	return nil
 }
+
+type RangeResponse struct {
+	Prefix  string                `json:"prefix"`
+	Matches []*domain.HashedScore `json:"matches"`
+}
+
+func isLangCode(s string) bool {
+	if len(s) != 2 {
+		return false
+	}
+	for _, c := range strings.ToLower(s) {
+		if c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// langFromHeader takes the primary language of the first Accept-Language entry.
+func langFromHeader(header string) string {
+	first, _, _ := strings.Cut(header, ",")
+	first, _, _ = strings.Cut(first, ";")
+	primary, _, _ := strings.Cut(strings.TrimSpace(first), "-")
+	if !isLangCode(primary) {
+		return ""
+	}
+	return strings.ToLower(primary)
+}
@@ -2,13 +2,21 @@ package http
 
 import (
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/rgdevment/spam-registry/internal/platform/logging"
 	"github.com/rgdevment/spam-registry/internal/service"
 )
 
+// MaxJSONBodyBytes caps JSON request bodies. A report is a few hundred
+// bytes; the cap only has to leave room for a long comment.
+const MaxJSONBodyBytes = 64 << 10
+
 type Handler struct {
 	service service.Service
 }
@@ -22,12 +30,20 @@ func NewHandler(s service.Service) *Handler {
 func (h *Handler) RegisterRoutes(r chi.Router) {
 	r.Post("/v1/reports", h.CreateReport)
 	r.Get("/v1/phone/{number}", h.CheckRisk)
+	r.Get("/v1/phone/{number}/reports", h.ListPhoneReports)
+	r.Get("/v1/range/{prefix}", h.CheckRiskRange)
 }
 
 func (h *Handler) CreateReport(w http.ResponseWriter, r *http.Request) {
 	var req CreateReportRequest
 
+	r.Body = http.MaxBytesReader(w, r.Body, MaxJSONBodyBytes)
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
 		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
 		return
 	}
@@ -42,25 +58,33 @@ func (h *Handler) CreateReport(w http.ResponseWriter, r *http.Request) {
 		reporterRaw = "anonymous"
 	}
 
+	lang := req.Lang
+	if lang == "" {
+		lang = langFromHeader(r.Header.Get("Accept-Language"))
+	}
+
 	err := h.service.IngestReport(
 		r.Context(),
 		req.PhoneNumber,
 		reporterRaw,
 		req.Category,
 		req.Comment,
+		lang,
 	)
 
 	if err != nil {
-		log.Printf("❌ ERROR IngestReport: %v", err)
-
 		if err.Error() == "invalid phone format: ensure it includes country code (e.g. +569...)" ||
 			err.Error() == "invalid phone number: number does not exist" ||
 			err.Error() == "could not detect country from phone number" {
 
+			slog.WarnContext(r.Context(), "report rejected",
+				logging.Phone("phone", req.PhoneNumber), logging.ErrClass(err, "invalid_input"))
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
+		slog.ErrorContext(r.Context(), "❌ ERROR IngestReport",
+			logging.Phone("phone", req.PhoneNumber), logging.Err(err))
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
@@ -79,6 +103,8 @@ func (h *Handler) CheckRisk(w http.ResponseWriter, r *http.Request) {
 
 	score, err := h.service.CheckRisk(r.Context(), phoneNumber)
 	if err != nil {
+		slog.ErrorContext(r.Context(), "❌ ERROR CheckRisk",
+			logging.Phone("phone", phoneNumber), logging.Err(err))
 		http.Error(w, "Error retrieval failed", http.StatusInternalServerError)
 		return
 	}
@@ -86,3 +112,71 @@ func (h *Handler) CheckRisk(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(score)
 }
+
+func (h *Handler) CheckRiskRange(w http.ResponseWriter, r *http.Request) {
+	prefix := chi.URLParam(r, "prefix")
+
+	matches, err := h.service.CheckRiskByHashPrefix(r.Context(), prefix)
+	if errors.Is(err, service.ErrInvalidHashPrefix) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "❌ ERROR CheckRiskByHashPrefix", logging.Err(err))
+		http.Error(w, "Error retrieval failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(RangeResponse{
+		Prefix:  strings.ToLower(prefix),
+		Matches: matches,
+	})
+}
+
+func (h *Handler) ListPhoneReports(w http.ResponseWriter, r *http.Request) {
+	phoneNumber := chi.URLParam(r, "number")
+
+	if len(phoneNumber) < 5 {
+		http.Error(w, "Invalid phone number", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+
+	page, limit := 1, service.DefaultPublicReportLimit
+	var err error
+	if v := query.Get("page"); v != "" {
+		if page, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "page must be a number", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := query.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "limit must be a number", http.StatusBadRequest)
+			return
+		}
+	}
+
+	lang := query.Get("lang")
+	if lang != "" && !isLangCode(lang) {
+		http.Error(w, "lang must be a two-letter ISO 639-1 code", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.service.ListPublicReports(r.Context(), phoneNumber, lang, page, limit)
+	if errors.Is(err, service.ErrInvalidPagination) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "❌ ERROR ListPublicReports",
+			logging.Phone("phone", phoneNumber), logging.Err(err))
+		http.Error(w, "Error retrieval failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,167 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+	httpHandler "github.com/rgdevment/spam-registry/internal/platform/http"
+	"github.com/rgdevment/spam-registry/internal/service"
+)
+
+type stubService struct {
+	service.Service
+	ingested int
+}
+
+func (s *stubService) IngestReport(ctx context.Context, rawPhone, rawReporter, category, comment, lang string) error {
+	s.ingested++
+	return nil
+}
+
+func TestCreateReportRejectsOversizedBodies(t *testing.T) {
+	svc := &stubService{}
+	r := chi.NewRouter()
+	httpHandler.NewHandler(svc).RegisterRoutes(r)
+
+	post := func(body string) int {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/reports", strings.NewReader(body)))
+		return rec.Code
+	}
+
+	comment := strings.Repeat("a", httpHandler.MaxJSONBodyBytes)
+	assert.Equal(t, http.StatusRequestEntityTooLarge,
+		post(`{"phone_number":"+56912345678","category":"SPAM","comment":"`+comment+`"}`))
+	assert.Zero(t, svc.ingested)
+
+	assert.Equal(t, http.StatusAccepted, post(`{"phone_number":"+56912345678","category":"SPAM","comment":"llamada robot"}`))
+	assert.Equal(t, 1, svc.ingested)
+}
+
+type openAPIDoc struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Enum       []string                   `json:"enum"`
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+func loadOpenAPI(t *testing.T) openAPIDoc {
+	t.Helper()
+	var doc openAPIDoc
+	require.NoError(t, json.Unmarshal(httpHandler.OpenAPISpec, &doc))
+	return doc
+}
+
+func TestOpenAPIDescribesEveryRoute(t *testing.T) {
+	r := chi.NewRouter()
+	httpHandler.NewHandler(&stubService{}).RegisterRoutes(r)
+
+	var routes []string
+	require.NoError(t, chi.Walk(r, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		routes = append(routes, method+" "+route)
+		return nil
+	}))
+
+	var documented []string
+	for path, ops := range loadOpenAPI(t).Paths {
+		for method := range ops {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+
+	assert.ElementsMatch(t, routes, documented, "openapi.json and RegisterRoutes drifted apart")
+}
+
+func TestOpenAPISchemasMatchTheJSONTypes(t *testing.T) {
+	schemas := loadOpenAPI(t).Components.Schemas
+
+	types := map[string]any{
+		"CreateReportRequest": httpHandler.CreateReportRequest{},
+		"RangeResponse":       httpHandler.RangeResponse{},
+		"PhoneScore":          domain.PhoneScore{},
+		"HashedScore":         domain.HashedScore{},
+		"PublicReport":        domain.PublicReport{},
+		"PublicReportPage":    domain.PublicReportPage{},
+	}
+	for name, v := range types {
+		schema, ok := schemas[name]
+		require.True(t, ok, "schema %s is missing", name)
+		assert.ElementsMatch(t, jsonFields(reflect.TypeOf(v)), slices.Collect(maps.Keys(schema.Properties)), "schema %s", name)
+	}
+
+	var categories []string
+	for _, c := range httpHandler.ReportCategories {
+		categories = append(categories, string(c))
+	}
+	assert.ElementsMatch(t, categories, schemas["Category"].Enum)
+	assert.ElementsMatch(t, []string{string(domain.LevelSafe), string(domain.LevelWarning), string(domain.LevelCritical)}, schemas["RiskLevel"].Enum)
+}
+
+func TestOpenAPIReferencesResolve(t *testing.T) {
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(httpHandler.OpenAPISpec, &doc))
+
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for k, child := range v {
+				if ref, ok := child.(string); ok && k == "$ref" {
+					var node any = doc
+					for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+						m, _ := node.(map[string]any)
+						node = m[part]
+					}
+					assert.NotNil(t, node, "dangling $ref %s", ref)
+				}
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(doc)
+}
+
+func TestServeOpenAPI(t *testing.T) {
+	rec := httptest.NewRecorder()
+	httpHandler.ServeOpenAPI(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, string(httpHandler.OpenAPISpec), rec.Body.String())
+}
+
+// jsonFields lists the names encoding/json gives the fields of t.
+func jsonFields(t reflect.Type) []string {
+	var names []string
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
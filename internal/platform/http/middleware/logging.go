@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+
+	"github.com/rgdevment/spam-registry/internal/platform/logging"
+)
+
+// AccessLog writes one line per request and stores the request ID and route
+// in the context, so every line logged while serving it carries them. The
+// URL path is never logged because it holds phone numbers. It must run after
+// chi's RequestID.
+func AccessLog(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			reqID := chiMiddleware.GetReqID(r.Context())
+			if reqID != "" {
+				w.Header().Set(chiMiddleware.RequestIDHeader, reqID)
+			}
+			ctx := logging.With(r.Context(),
+				slog.String("request_id", reqID),
+				slog.Any("route", routeValue{chi.RouteContext(r.Context())}),
+			)
+
+			ww := chiMiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			level := slog.LevelInfo
+			if status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			logger.LogAttrs(ctx, level, "request",
+				slog.String("method", r.Method),
+				slog.Int("status", status),
+				slog.Int("bytes", ww.BytesWritten()),
+				slog.Duration("duration", time.Since(start)),
+			)
+		})
+	}
+}
+
+// routeValue resolves to the chi route pattern when the line is written,
+// which is only known once the request has been routed.
+type routeValue struct {
+	rctx *chi.Context
+}
+
+func (v routeValue) LogValue() slog.Value {
+	if v.rctx == nil || v.rctx.RoutePattern() == "" {
+		return slog.StringValue("unmatched")
+	}
+	return slog.StringValue(v.rctx.RoutePattern())
+}
@@ -0,0 +1,20 @@
+package http
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// OpenAPISpec is the OpenAPI 3 document of the routes RegisterRoutes mounts.
+// Edit openapi.json along with the handlers; the tests fail when they drift.
+//
+//go:embed openapi.json
+var OpenAPISpec []byte
+
+// ServeOpenAPI serves OpenAPISpec. It needs no API key: integrators read it
+// before they have one.
+func ServeOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.Write(OpenAPISpec)
+}
@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net"
+	"strings"
+)
+
+// phone is only ever written through the logger's policy. Its String is the
+// masked form, so a handler built elsewhere still cannot leak the number.
+type phone string
+
+func (p phone) String() string { return maskPhone(string(p)) }
+
+func (p phone) MarshalText() ([]byte, error) { return []byte(p.String()), nil }
+
+// Phone logs a phone number under the configured policy.
+func Phone(key, number string) slog.Attr {
+	return slog.Any(key, phone(number))
+}
+
+func maskPhone(number string) string {
+	runes := []rune(number)
+	if len(runes) <= 6 {
+		return strings.Repeat("*", len(runes))
+	}
+	masked := make([]rune, len(runes))
+	for i, r := range runes {
+		if i < 4 || i >= len(runes)-2 {
+			masked[i] = r
+		} else {
+			masked[i] = '*'
+		}
+	}
+	return string(masked)
+}
+
+func phoneReplacer(cfg Config) func([]string, slog.Attr) slog.Attr {
+	return func(_ []string, a slog.Attr) slog.Attr {
+		p, ok := a.Value.Any().(phone)
+		if !ok || a.Value.Kind() != slog.KindAny {
+			return a
+		}
+		if cfg.PhonePolicy == PhoneHash {
+			mac := hmac.New(sha256.New, []byte(cfg.PhoneHashKey))
+			mac.Write([]byte(p))
+			return slog.String(a.Key, "h:"+hex.EncodeToString(mac.Sum(nil))[:16])
+		}
+		return slog.String(a.Key, p.String())
+	}
+}
+
+// ErrorClasser lets an error name its own class in the logs.
+type ErrorClasser interface {
+	ErrorClass() string
+}
+
+// Classify sorts err into a coarse class for alerting: canceled, timeout,
+// the error's own ErrorClass, or internal.
+func Classify(err error) string {
+	var classer ErrorClasser
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.As(err, &classer):
+		return classer.ErrorClass()
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.As(err, &netErr):
+		return "network"
+	default:
+		return "internal"
+	}
+}
+
+// Err logs err with its class.
+func Err(err error) slog.Attr {
+	return ErrClass(err, Classify(err))
+}
+
+// ErrClass logs err under an explicit class, for errors whose class only the
+// caller knows, such as rejected input.
+func ErrClass(err error, class string) slog.Attr {
+	if err == nil {
+		return slog.Attr{}
+	}
+	return slog.Group("error", slog.String("msg", err.Error()), slog.String("class", class))
+}
@@ -0,0 +1,126 @@
+// Package logging builds the slog logger shared by the binaries. Lines carry
+// the request attributes stored in their context, and phone numbers are only
+// written masked or hashed because the output ends up in the log aggregator.
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+
+	// PhoneMask keeps the country prefix and the last two digits.
+	PhoneMask = "mask"
+	// PhoneHash writes a keyed hash, so lines about one number can be joined
+	// without the number being recoverable from the logs.
+	PhoneHash = "hash"
+)
+
+type Config struct {
+	Level        slog.Level
+	Format       string
+	PhonePolicy  string
+	PhoneHashKey string
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Level:       slog.LevelInfo,
+		Format:      FormatText,
+		PhonePolicy: PhoneMask,
+	}
+}
+
+func (c Config) Validate() error {
+	switch c.Format {
+	case FormatText, FormatJSON:
+	default:
+		return fmt.Errorf("logging: unknown format %q (want text or json)", c.Format)
+	}
+	switch c.PhonePolicy {
+	case PhoneMask:
+	case PhoneHash:
+		if c.PhoneHashKey == "" {
+			return errors.New("logging: the hash phone policy needs LOG_PHONE_HASH_KEY")
+		}
+	default:
+		return fmt.Errorf("logging: unknown phone policy %q (want mask or hash)", c.PhonePolicy)
+	}
+	return nil
+}
+
+// New returns a logger writing to w.
+func New(w io.Writer, cfg Config) (*slog.Logger, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	opts := &slog.HandlerOptions{
+		Level:       cfg.Level,
+		ReplaceAttr: phoneReplacer(cfg),
+	}
+
+	var h slog.Handler
+	if cfg.Format == FormatJSON {
+		h = slog.NewJSONHandler(w, opts)
+	} else {
+		h = slog.NewTextHandler(w, opts)
+	}
+	return slog.New(contextHandler{h}), nil
+}
+
+// Setup installs a stderr logger as the slog default, which also routes
+// what is still written with the log package.
+func Setup(cfg Config) error {
+	logger, err := New(os.Stderr, cfg)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+type attrsKey struct{}
+
+// With returns a context whose log lines carry attrs, after those already
+// stored in ctx.
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	prev, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	merged := make([]slog.Attr, 0, len(prev)+len(attrs))
+	merged = append(merged, prev...)
+	merged = append(merged, attrs...)
+	return context.WithValue(ctx, attrsKey{}, merged)
+}
+
+// contextHandler adds the attributes stored by With and the trace ID of the
+// active span to every record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs, ok := ctx.Value(attrsKey{}).([]slog.Attr); ok {
+		r.AddAttrs(attrs...)
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
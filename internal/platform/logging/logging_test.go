@@ -0,0 +1,108 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	middleware "github.com/rgdevment/spam-registry/internal/platform/http/middleware"
+	"github.com/rgdevment/spam-registry/internal/platform/logging"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/breaker"
+)
+
+const phone = "+56912345678"
+
+func jsonLogger(t *testing.T, policy string) (*slog.Logger, *bytes.Buffer) {
+	t.Helper()
+	cfg := logging.DefaultConfig()
+	cfg.Format = logging.FormatJSON
+	cfg.PhonePolicy = policy
+	cfg.PhoneHashKey = "log-key"
+
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, cfg)
+	require.NoError(t, err)
+	return logger, &buf
+}
+
+func lines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		out = append(out, entry)
+	}
+	return out
+}
+
+func TestPhonesAreNeverWrittenInClear(t *testing.T) {
+	masked, buf := jsonLogger(t, logging.PhoneMask)
+	masked.Info("lookup", logging.Phone("phone", phone))
+	assert.Equal(t, "+569******78", lines(t, buf)[0]["phone"])
+
+	hashed, buf := jsonLogger(t, logging.PhoneHash)
+	hashed.Info("lookup", logging.Phone("phone", phone))
+	hashed.Info("lookup", logging.Phone("phone", phone))
+	entries := lines(t, buf)
+	assert.Equal(t, entries[0]["phone"], entries[1]["phone"], "the hash joins lines about one number")
+	assert.NotContains(t, buf.String(), "912345678")
+
+	assert.Equal(t, "+569******78", fmt.Sprint(logging.Phone("phone", phone).Value), "foreign handlers see the mask")
+}
+
+func TestHashPolicyNeedsKey(t *testing.T) {
+	cfg := logging.DefaultConfig()
+	cfg.PhonePolicy = logging.PhoneHash
+	assert.Error(t, cfg.Validate())
+}
+
+func TestErrorClasses(t *testing.T) {
+	assert.Equal(t, "timeout", logging.Classify(fmt.Errorf("query: %w", context.DeadlineExceeded)))
+	assert.Equal(t, "canceled", logging.Classify(context.Canceled))
+	assert.Equal(t, "unavailable", logging.Classify(fmt.Errorf("lookup: %w", breaker.ErrOpen)))
+	assert.Equal(t, "internal", logging.Classify(errors.New("boom")))
+}
+
+func TestRequestLinesCarryRequestIDAndRoute(t *testing.T) {
+	logger, buf := jsonLogger(t, logging.PhoneMask)
+
+	r := chi.NewRouter()
+	r.Use(chiMiddleware.RequestID)
+	r.Use(middleware.AccessLog(logger))
+	r.Get("/v1/phone/{number}", func(w http.ResponseWriter, r *http.Request) {
+		logger.ErrorContext(r.Context(), "lookup failed",
+			logging.Phone("phone", chi.URLParam(r, "number")), logging.Err(breaker.ErrOpen))
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/phone/"+phone, nil)
+	req.Header.Set(chiMiddleware.RequestIDHeader, "req-42")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, "req-42", rec.Header().Get(chiMiddleware.RequestIDHeader))
+	assert.NotContains(t, buf.String(), "912345678")
+
+	entries := lines(t, buf)
+	require.Len(t, entries, 2)
+	for _, entry := range entries {
+		assert.Equal(t, "req-42", entry["request_id"])
+		assert.Equal(t, "/v1/phone/{number}", entry["route"])
+	}
+	assert.Equal(t, map[string]any{"msg": breaker.ErrOpen.Error(), "class": "unavailable"}, entries[0]["error"])
+	assert.Equal(t, "ERROR", entries[1]["level"])
+	assert.EqualValues(t, 500, entries[1]["status"])
+}
@@ -0,0 +1,162 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/cache"
+)
+
+const namespace = "gsr"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by chi route pattern, method and status.",
+	}, []string{"route", "method", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by chi route pattern, method and status.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"route", "method", "status"})
+
+	reportsIngested = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reports_ingested_total",
+		Help:      "Accepted reports by category and country, spooled ones included.",
+	}, []string{"category", "country"})
+
+	reportsSpooled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reports_spooled_total",
+		Help:      "Reports written to the local spool because the store refused them.",
+	}, []string{"country"})
+
+	aggregateFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "aggregate_update_failures_total",
+		Help:      "Stored reports the aggregate of their number could not take; worker -rebuild repairs it.",
+	}, []string{"country"})
+
+	recalculationDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "recalculation_duration_seconds",
+		Help:      "Time to recalculate and store the score of one number.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	})
+
+	levelTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "score_level_transitions_total",
+		Help:      "Recalculations that moved a number to another risk level.",
+	}, []string{"from", "to"})
+
+	storageDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "operation_duration_seconds",
+		Help:      "Repository call latency by backend and method.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 5},
+	}, []string{"backend", "method"})
+
+	storageErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "operation_errors_total",
+		Help:      "Failed repository calls by backend and method.",
+	}, []string{"backend", "method"})
+)
+
+// Handler serves every registered metric in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveRequest records one HTTP request. route is the chi pattern, never
+// the raw path, so phone numbers stay out of the label values.
+func ObserveRequest(route, method string, status int, elapsed time.Duration) {
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(route, method, code).Inc()
+	httpDuration.WithLabelValues(route, method, code).Observe(elapsed.Seconds())
+}
+
+// Observer implements service.Observer.
+type Observer struct{}
+
+func (Observer) ReportIngested(category domain.RiskCategory, countryCode string) {
+	reportsIngested.WithLabelValues(string(category), countryCode).Inc()
+}
+
+func (Observer) ReportSpooled(countryCode string) {
+	reportsSpooled.WithLabelValues(countryCode).Inc()
+}
+
+func (Observer) AggregateUpdateFailed(countryCode string) {
+	aggregateFailures.WithLabelValues(countryCode).Inc()
+}
+
+func (Observer) RiskRecalculated(elapsed time.Duration, from, to domain.RiskLevel) {
+	recalculationDuration.Observe(elapsed.Seconds())
+	if from != to {
+		levelTransitions.WithLabelValues(string(from), string(to)).Inc()
+	}
+}
+
+// RegisterCache exposes the counters of a score cache.
+func RegisterCache(c *cache.Repository) {
+	counter := func(name, help string, value func(cache.Stats) uint64) {
+		promauto.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "score_cache",
+			Name:      name,
+			Help:      help,
+		}, func() float64 { return float64(value(c.Stats())) })
+	}
+
+	counter("hits_total", "Score lookups answered from the cache.", func(s cache.Stats) uint64 { return s.Hits })
+	counter("negative_hits_total", "Cache hits for numbers without a score.", func(s cache.Stats) uint64 { return s.NegativeHits })
+	counter("misses_total", "Score lookups that went to the store.", func(s cache.Stats) uint64 { return s.Misses })
+	counter("errors_total", "Failed cache reads, writes and invalidations.", func(s cache.Stats) uint64 { return s.Errors })
+
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "score_cache",
+		Name:      "hit_ratio",
+		Help:      "Share of score lookups answered from the cache since start.",
+	}, func() float64 { return c.Stats().HitRatio() })
+}
+
+// RegisterSpool exposes the bytes waiting in the report spool.
+func RegisterSpool(backlog func() (int64, error)) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "spool",
+		Name:      "backlog_bytes",
+		Help:      "Bytes of spooled reports not yet replayed into the store.",
+	}, func() float64 {
+		n, err := backlog()
+		if err != nil {
+			return -1
+		}
+		return float64(n)
+	})
+}
+
+// Mux serves /metrics for a listener of its own, away from the public API
+// and its authentication.
+func Mux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	return mux
+}
@@ -0,0 +1,49 @@
+package metrics_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rgdevment/spam-registry/internal/platform/http/middleware"
+	"github.com/rgdevment/spam-registry/internal/platform/metrics"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/memory"
+)
+
+func scrape(t *testing.T) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	return rec.Body.String()
+}
+
+func TestRequestsAreLabelledByRoutePattern(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(middleware.Metrics)
+	r.Get("/v1/phone/{number}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/phone/+56911111111", nil))
+
+	body := scrape(t)
+	assert.Contains(t, body, `gsr_http_requests_total{method="GET",route="/v1/phone/{number}",status="418"} 1`)
+	assert.NotContains(t, body, "+56911111111", "raw paths must never become label values")
+}
+
+func TestRepositoryCallsAreTimedPerMethod(t *testing.T) {
+	repo := metrics.NewRepository(memory.NewMemoryRepository(), "test")
+
+	_, err := repo.GetScore(context.Background(), "+56911111111")
+	require.NoError(t, err)
+	_, err = repo.GetScore(context.Background(), "+56922222222")
+	require.NoError(t, err)
+
+	assert.Contains(t, scrape(t), `gsr_storage_operation_duration_seconds_count{backend="test",method="GetScore"} 2`)
+}
@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"context"
+	"iter"
+	"time"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+	"github.com/rgdevment/spam-registry/internal/service"
+)
+
+// repository times every call to the wrapped store and counts its errors.
+type repository struct {
+	inner   service.Repository
+	backend string
+}
+
+func NewRepository(inner service.Repository, backend string) service.Repository {
+	return &repository{inner: inner, backend: backend}
+}
+
+func (r *repository) observe(method string, start time.Time, err error) {
+	storageDuration.WithLabelValues(r.backend, method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		storageErrors.WithLabelValues(r.backend, method).Inc()
+	}
+}
+
+func (r *repository) SaveRawReport(ctx context.Context, report *domain.Report) error {
+	start := time.Now()
+	err := r.inner.SaveRawReport(ctx, report)
+	r.observe("SaveRawReport", start, err)
+	return err
+}
+
+func (r *repository) GetRawReports(ctx context.Context, phoneNumber string) ([]*domain.Report, error) {
+	start := time.Now()
+	result, err := r.inner.GetRawReports(ctx, phoneNumber)
+	r.observe("GetRawReports", start, err)
+	return result, err
+}
+
+// StreamRawReports is timed from the first page to the end of the range
+// loop, which includes the caller's work between pages.
+func (r *repository) StreamRawReports(ctx context.Context, phoneNumber string, rng service.ReportRange) iter.Seq2[*domain.Report, error] {
+	return func(yield func(*domain.Report, error) bool) {
+		start := time.Now()
+		var failed error
+		for report, err := range r.inner.StreamRawReports(ctx, phoneNumber, rng) {
+			failed = err
+			if !yield(report, err) {
+				break
+			}
+		}
+		r.observe("StreamRawReports", start, failed)
+	}
+}
+
+func (r *repository) UpdateReporterHash(ctx context.Context, report *domain.Report) error {
+	start := time.Now()
+	err := r.inner.UpdateReporterHash(ctx, report)
+	r.observe("UpdateReporterHash", start, err)
+	return err
+}
+
+func (r *repository) UpsertScore(ctx context.Context, s *domain.PhoneScore, ttlSeconds int) error {
+	start := time.Now()
+	err := r.inner.UpsertScore(ctx, s, ttlSeconds)
+	r.observe("UpsertScore", start, err)
+	return err
+}
+
+func (r *repository) UpsertCountryThreat(ctx context.Context, s *domain.PhoneScore, ttlSeconds int) error {
+	start := time.Now()
+	err := r.inner.UpsertCountryThreat(ctx, s, ttlSeconds)
+	r.observe("UpsertCountryThreat", start, err)
+	return err
+}
+
+func (r *repository) ListCountryThreats(ctx context.Context, countryCode string) ([]*domain.PhoneScore, error) {
+	start := time.Now()
+	result, err := r.inner.ListCountryThreats(ctx, countryCode)
+	r.observe("ListCountryThreats", start, err)
+	return result, err
+}
+
+func (r *repository) DeleteScore(ctx context.Context, phoneNumber string, countryCode string) error {
+	start := time.Now()
+	err := r.inner.DeleteScore(ctx, phoneNumber, countryCode)
+	r.observe("DeleteScore", start, err)
+	return err
+}
+
+func (r *repository) GetScore(ctx context.Context, phoneNumber string) (*domain.PhoneScore, error) {
+	start := time.Now()
+	result, err := r.inner.GetScore(ctx, phoneNumber)
+	r.observe("GetScore", start, err)
+	return result, err
+}
+
+func (r *repository) GetScoresByHashPrefix(ctx context.Context, prefix string) ([]*domain.HashedScore, error) {
+	start := time.Now()
+	result, err := r.inner.GetScoresByHashPrefix(ctx, prefix)
+	r.observe("GetScoresByHashPrefix", start, err)
+	return result, err
+}
+
+func (r *repository) EnqueueModeration(ctx context.Context, item *domain.ModerationItem) error {
+	start := time.Now()
+	err := r.inner.EnqueueModeration(ctx, item)
+	r.observe("EnqueueModeration", start, err)
+	return err
+}
+
+func (r *repository) ListModerationQueue(ctx context.Context, countryCode string, limit int) ([]*domain.ModerationItem, error) {
+	start := time.Now()
+	result, err := r.inner.ListModerationQueue(ctx, countryCode, limit)
+	r.observe("ListModerationQueue", start, err)
+	return result, err
+}
+
+func (r *repository) GetAggregate(ctx context.Context, phoneNumber string) (*domain.PhoneAggregate, error) {
+	start := time.Now()
+	result, err := r.inner.GetAggregate(ctx, phoneNumber)
+	r.observe("GetAggregate", start, err)
+	return result, err
+}
+
+func (r *repository) CompareAndSwapAggregate(ctx context.Context, agg *domain.PhoneAggregate, ttlSeconds int) (bool, error) {
+	start := time.Now()
+	result, err := r.inner.CompareAndSwapAggregate(ctx, agg, ttlSeconds)
+	r.observe("CompareAndSwapAggregate", start, err)
+	return result, err
+}
@@ -0,0 +1,463 @@
+package spool
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rgdevment/spam-registry/internal/platform/logging"
+)
+
+// ErrRecordTooLarge rejects payloads that could not be read back.
+var ErrRecordTooLarge = errors.New("spool: record too large")
+
+const (
+	segmentExt  = ".seg"
+	cursorFile  = "cursor"
+	headerSize  = 8
+	maxRecord   = 1 << 20
+	defaultSize = 64 << 20
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+type Option func(*Spool)
+
+// WithSegmentSize sets the size after which appends move to a new segment.
+func WithSegmentSize(bytes int64) Option {
+	return func(s *Spool) {
+		s.segmentSize = bytes
+	}
+}
+
+// Spool is a durable FIFO of opaque records on local disk. Records are
+// appended to numbered segment files, each one framed as
+//
+//	length uint32 | crc32c(payload) uint32 | payload
+//
+// and fsynced before Append returns. A cursor file remembers how far Replay
+// got; segments behind it are deleted.
+type Spool struct {
+	dir         string
+	segmentSize int64
+
+	mu         sync.Mutex
+	active     *os.File
+	activeSeq  uint64
+	activeSize int64
+
+	// replayMu serialises Replay calls and guards the cursor.
+	replayMu  sync.Mutex
+	cursorSeq uint64
+	cursorOff int64
+}
+
+// Open creates dir if needed and opens the spool in it. A record torn by a
+// crash at the end of the last segment is cut off.
+func Open(dir string, opts ...Option) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("spool: %w", err)
+	}
+
+	s := &Spool{dir: dir, segmentSize: defaultSize}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if err := s.readCursor(); err != nil {
+		return nil, err
+	}
+
+	segments, err := s.segments()
+	if err != nil {
+		return nil, err
+	}
+
+	seq := max(s.cursorSeq, 1)
+	if len(segments) > 0 {
+		seq = max(seq, segments[len(segments)-1])
+	}
+	if err := s.openActive(seq); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Append stores payload durably. It only returns once the record is on disk.
+func (s *Spool) Append(payload []byte) error {
+	if len(payload) > maxRecord {
+		return ErrRecordTooLarge
+	}
+
+	record := make([]byte, headerSize+len(payload))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.Checksum(payload, castagnoli))
+	copy(record[headerSize:], payload)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.activeSize > 0 && s.activeSize+int64(len(record)) > s.segmentSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.active.Write(record); err != nil {
+		return fmt.Errorf("spool: append failed: %w", err)
+	}
+	if err := s.active.Sync(); err != nil {
+		return fmt.Errorf("spool: fsync failed: %w", err)
+	}
+	s.activeSize += int64(len(record))
+
+	return nil
+}
+
+// Replay hands every pending record, oldest first, to fn. It stops at the
+// first error and leaves that record pending for the next call, so fn must
+// be idempotent. It returns how many records were consumed.
+func (s *Spool) Replay(ctx context.Context, fn func(ctx context.Context, payload []byte) error) (int, error) {
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+
+	s.mu.Lock()
+	activeSeq, activeSize := s.activeSeq, s.activeSize
+	s.mu.Unlock()
+
+	segments, err := s.segments()
+	if err != nil {
+		return 0, err
+	}
+
+	consumed := 0
+	for _, seq := range segments {
+		if seq < s.cursorSeq || seq > activeSeq {
+			continue
+		}
+
+		offset := int64(0)
+		if seq == s.cursorSeq {
+			offset = s.cursorOff
+		}
+		limit := int64(-1)
+		if seq == activeSeq {
+			limit = activeSize
+		}
+
+		n, next, err := s.replaySegment(ctx, seq, offset, limit, fn)
+		consumed += n
+		if err != nil {
+			if cursorErr := s.writeCursor(seq, next); cursorErr != nil {
+				return consumed, cursorErr
+			}
+			return consumed, err
+		}
+
+		if seq == activeSeq {
+			if err := s.writeCursor(seq, next); err != nil {
+				return consumed, err
+			}
+			break
+		}
+
+		// The whole segment is done: point the cursor at the next one before
+		// removing it, so a crash in between never replays it again.
+		if err := s.writeCursor(seq+1, 0); err != nil {
+			return consumed, err
+		}
+		if err := os.Remove(s.segmentPath(seq)); err != nil {
+			return consumed, fmt.Errorf("spool: %w", err)
+		}
+	}
+
+	return consumed, nil
+}
+
+func (s *Spool) replaySegment(ctx context.Context, seq uint64, offset, limit int64, fn func(context.Context, []byte) error) (int, int64, error) {
+	f, err := os.Open(s.segmentPath(seq))
+	if err != nil {
+		return 0, offset, fmt.Errorf("spool: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, offset, fmt.Errorf("spool: %w", err)
+	}
+
+	var src io.Reader = f
+	if limit >= 0 {
+		src = io.LimitReader(f, limit-offset)
+	}
+	reader := bufio.NewReader(src)
+
+	consumed := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return consumed, offset, err
+		}
+
+		payload, size, err := readRecord(reader)
+		if errors.Is(err, io.EOF) {
+			return consumed, offset, nil
+		}
+		if err != nil {
+			// Nothing after a corrupt frame can be trusted: its length may be
+			// wrong too. Skip the rest of the segment.
+			slog.Warn("⚠️  spool: corrupt record, skipping the rest of the segment",
+				"segment", s.segmentPath(seq), "offset", offset, logging.Err(err))
+			if limit >= 0 {
+				return consumed, limit, nil
+			}
+			info, statErr := f.Stat()
+			if statErr != nil {
+				return consumed, offset, fmt.Errorf("spool: %w", statErr)
+			}
+			return consumed, info.Size(), nil
+		}
+
+		if err := fn(ctx, payload); err != nil {
+			return consumed, offset, err
+		}
+		offset += size
+		consumed++
+
+		if consumed%64 == 0 {
+			if err := s.writeCursor(seq, offset); err != nil {
+				return consumed, offset, err
+			}
+		}
+	}
+}
+
+// Run replays the spool every interval until ctx is done.
+func (s *Spool) Run(ctx context.Context, interval time.Duration, fn func(ctx context.Context, payload []byte) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.Replay(ctx, fn)
+			if n > 0 {
+				slog.Info("📤 spool: records replayed", "count", n)
+			}
+			if err != nil && ctx.Err() == nil {
+				slog.Warn("⚠️  spool: replay paused", logging.Err(err))
+			}
+		}
+	}
+}
+
+// Backlog is the number of bytes not yet replayed.
+func (s *Spool) Backlog() (int64, error) {
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+
+	segments, err := s.segments()
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, seq := range segments {
+		if seq < s.cursorSeq {
+			continue
+		}
+		info, err := os.Stat(s.segmentPath(seq))
+		if err != nil {
+			return 0, fmt.Errorf("spool: %w", err)
+		}
+		total += info.Size()
+		if seq == s.cursorSeq {
+			total -= s.cursorOff
+		}
+	}
+	return total, nil
+}
+
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.active.Close()
+}
+
+func readRecord(r *bufio.Reader) ([]byte, int64, error) {
+	var header [headerSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, 0, fmt.Errorf("torn header")
+		}
+		return nil, 0, err
+	}
+
+	length := binary.BigEndian.Uint32(header[0:4])
+	if length > maxRecord {
+		return nil, 0, fmt.Errorf("record length %d out of range", length)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, 0, fmt.Errorf("torn record")
+	}
+	if crc32.Checksum(payload, castagnoli) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, 0, fmt.Errorf("checksum mismatch")
+	}
+
+	return payload, int64(headerSize + length), nil
+}
+
+// openActive opens segment seq for appending, truncating any torn tail.
+func (s *Spool) openActive(seq uint64) error {
+	path := s.segmentPath(seq)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return fmt.Errorf("spool: %w", err)
+	}
+
+	valid, err := validPrefix(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if info, err := f.Stat(); err == nil && info.Size() > valid {
+		slog.Warn("⚠️  spool: truncating torn tail", "bytes", info.Size()-valid, "segment", path)
+		if err := f.Truncate(valid); err != nil {
+			f.Close()
+			return fmt.Errorf("spool: %w", err)
+		}
+	}
+	if _, err := f.Seek(valid, io.SeekStart); err != nil {
+		f.Close()
+		return fmt.Errorf("spool: %w", err)
+	}
+
+	s.active, s.activeSeq, s.activeSize = f, seq, valid
+	return syncDir(s.dir)
+}
+
+func (s *Spool) rotate() error {
+	if err := s.active.Close(); err != nil {
+		return fmt.Errorf("spool: %w", err)
+	}
+	return s.openActive(s.activeSeq + 1)
+}
+
+// validPrefix returns the length of the leading run of intact records.
+func validPrefix(f *os.File) (int64, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("spool: %w", err)
+	}
+
+	reader := bufio.NewReader(f)
+	var valid int64
+	for {
+		_, size, err := readRecord(reader)
+		if err != nil {
+			return valid, nil
+		}
+		valid += size
+	}
+}
+
+func (s *Spool) segments() ([]uint64, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("spool: %w", err)
+	}
+
+	var seqs []uint64
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	return seqs, nil
+}
+
+func (s *Spool) segmentPath(seq uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d%s", seq, segmentExt))
+}
+
+func (s *Spool) readCursor() error {
+	data, err := os.ReadFile(filepath.Join(s.dir, cursorFile))
+	if errors.Is(err, os.ErrNotExist) {
+		s.cursorSeq, s.cursorOff = 1, 0
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("spool: %w", err)
+	}
+
+	if _, err := fmt.Sscanf(string(data), "%d %d", &s.cursorSeq, &s.cursorOff); err != nil {
+		return fmt.Errorf("spool: corrupt cursor file: %w", err)
+	}
+	return nil
+}
+
+// writeCursor replaces the cursor file atomically and durably.
+func (s *Spool) writeCursor(seq uint64, offset int64) error {
+	if seq == s.cursorSeq && offset == s.cursorOff {
+		return nil
+	}
+
+	path := filepath.Join(s.dir, cursorFile)
+	tmp, err := os.CreateTemp(s.dir, cursorFile+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("spool: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := fmt.Fprintf(tmp, "%d %d\n", seq, offset); err != nil {
+		tmp.Close()
+		return fmt.Errorf("spool: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("spool: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("spool: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("spool: %w", err)
+	}
+
+	s.cursorSeq, s.cursorOff = seq, offset
+	return syncDir(s.dir)
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("spool: %w", err)
+	}
+	defer d.Close()
+
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("spool: fsync of %s failed: %w", dir, err)
+	}
+	return nil
+}
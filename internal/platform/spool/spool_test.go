@@ -0,0 +1,109 @@
+package spool_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rgdevment/spam-registry/internal/platform/spool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func collect(t *testing.T, s *spool.Spool) []string {
+	t.Helper()
+	var got []string
+	_, err := s.Replay(context.Background(), func(ctx context.Context, payload []byte) error {
+		got = append(got, string(payload))
+		return nil
+	})
+	require.NoError(t, err)
+	return got
+}
+
+func TestReplayDrainsAcrossSegmentsAndRestarts(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := spool.Open(dir, spool.WithSegmentSize(64))
+	require.NoError(t, err)
+	for i := range 10 {
+		require.NoError(t, s.Append(fmt.Appendf(nil, "report-%d", i)))
+	}
+
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.seg"))
+	assert.Greater(t, len(segments), 1, "small segments must rotate")
+
+	failAt := "report-4"
+	n, err := s.Replay(context.Background(), func(ctx context.Context, payload []byte) error {
+		if string(payload) == failAt {
+			return errors.New("store down")
+		}
+		return nil
+	})
+	require.Error(t, err)
+	assert.Equal(t, 4, n)
+	require.NoError(t, s.Close())
+
+	reopened, err := spool.Open(dir, spool.WithSegmentSize(64))
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	require.NoError(t, reopened.Append([]byte("report-10")))
+	got := collect(t, reopened)
+	assert.Equal(t, []string{"report-4", "report-5", "report-6", "report-7", "report-8", "report-9", "report-10"}, got)
+
+	backlog, err := reopened.Backlog()
+	require.NoError(t, err)
+	assert.Zero(t, backlog)
+	assert.Empty(t, collect(t, reopened), "replayed records are not replayed again")
+
+	segments, _ = filepath.Glob(filepath.Join(dir, "*.seg"))
+	assert.Len(t, segments, 1, "consumed segments are removed")
+}
+
+func TestTornTailIsCutOnOpen(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := spool.Open(dir)
+	require.NoError(t, err)
+	require.NoError(t, s.Append([]byte("complete")))
+	require.NoError(t, s.Close())
+
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.seg"))
+	require.Len(t, segments, 1)
+	f, err := os.OpenFile(segments[0], os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0, 0, 0, 9, 1, 2})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	reopened, err := spool.Open(dir)
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	require.NoError(t, reopened.Append([]byte("after-crash")))
+	assert.Equal(t, []string{"complete", "after-crash"}, collect(t, reopened))
+}
+
+func TestCorruptRecordSkipsRestOfSegment(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := spool.Open(dir, spool.WithSegmentSize(40))
+	require.NoError(t, err)
+	defer s.Close()
+	for _, payload := range []string{"first-aaaaaaaaaa", "second-aaaaaaaaa", "third-aaaaaaaaaa"} {
+		require.NoError(t, s.Append([]byte(payload)))
+	}
+
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.seg"))
+	require.Len(t, segments, 3)
+	data, err := os.ReadFile(segments[1])
+	require.NoError(t, err)
+	data[len(data)-1] ^= 0xff
+	require.NoError(t, os.WriteFile(segments[1], data, 0o600))
+
+	assert.Equal(t, []string{"first-aaaaaaaaaa", "third-aaaaaaaaaa"}, collect(t, s))
+}
@@ -0,0 +1,138 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/rgdevment/spam-registry/internal/platform/crypto/envelope"
+	"github.com/rgdevment/spam-registry/internal/platform/logging"
+	"github.com/rgdevment/spam-registry/internal/platform/metrics"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/breaker"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/cache"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/memory"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/scylla"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/sqlite"
+	"github.com/rgdevment/spam-registry/internal/service"
+)
+
+const (
+	KindScylla = "scylla"
+	KindMemory = "memory"
+	KindSQLite = "sqlite"
+)
+
+type Config struct {
+	Kind string
+
+	Scylla scylla.Config
+
+	// Breaker guards Scylla lookups and report saves so the API can fall
+	// back to its blocklist snapshot and spool without waiting on timeouts.
+	Breaker breaker.Config
+
+	MemorySnapshotPath string
+
+	SQLitePath string
+
+	// ScoreCache sizes the read-through cache the API puts in front of
+	// score lookups.
+	ScoreCache cache.Config
+}
+
+// Backend is an opened storage backend. Background jobs it needs (snapshots,
+// TTL sweeping) run until ctx passed to Open is done; Close releases the rest.
+type Backend struct {
+	Kind       string
+	Repository service.Repository
+	KeyStore   envelope.KeyStore
+
+	close       func()
+	checkSchema func(ctx context.Context) error
+	ping        func(ctx context.Context) error
+}
+
+func Open(ctx context.Context, cfg Config) (*Backend, error) {
+	switch cfg.Kind {
+	case KindScylla:
+		session, err := scylla.NewSession(cfg.Scylla)
+		if err != nil {
+			return nil, err
+		}
+
+		repo := metrics.NewRepository(scylla.NewScyllaRepository(session, scylla.WithConfig(cfg.Scylla)), cfg.Kind)
+
+		return &Backend{
+			Kind:       cfg.Kind,
+			Repository: breaker.NewRepository(repo, breaker.New(cfg.Breaker)),
+			KeyStore:   scylla.NewDataKeyStore(session),
+			close:      session.Close,
+			checkSchema: func(ctx context.Context) error {
+				return scylla.VerifySchema(ctx, session)
+			},
+			ping: func(ctx context.Context) error {
+				return scylla.Ping(ctx, session)
+			},
+		}, nil
+
+	case KindMemory:
+		repo := memory.NewMemoryRepository()
+		b := &Backend{Kind: cfg.Kind, Repository: metrics.NewRepository(repo, cfg.Kind), KeyStore: repo, close: repo.Close}
+
+		if path := cfg.MemorySnapshotPath; path != "" {
+			if err := repo.LoadSnapshot(path); err != nil {
+				return nil, err
+			}
+			go repo.RunSnapshots(ctx, path, time.Minute)
+
+			b.close = func() {
+				repo.Close()
+				if err := repo.SaveSnapshot(path); err != nil {
+					slog.Warn("⚠️  Memory snapshot not saved", logging.Err(err))
+				}
+			}
+		}
+
+		return b, nil
+
+	case KindSQLite:
+		db, err := sqlite.Open(ctx, cfg.SQLitePath)
+		if err != nil {
+			return nil, err
+		}
+		go sqlite.RunSweeper(ctx, db, 10*time.Minute)
+
+		return &Backend{
+			Kind:       cfg.Kind,
+			Repository: metrics.NewRepository(sqlite.NewSQLiteRepository(db), cfg.Kind),
+			KeyStore:   sqlite.NewDataKeyStore(db),
+			close:      func() { db.Close() },
+			ping:       db.PingContext,
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q (use scylla, memory or sqlite)", cfg.Kind)
+	}
+}
+
+// CheckSchema verifies the store is migrated far enough for this binary.
+// Embedded backends migrate themselves on Open and always pass.
+func (b *Backend) CheckSchema(ctx context.Context) error {
+	if b.checkSchema == nil {
+		return nil
+	}
+	return b.checkSchema(ctx)
+}
+
+// Ping checks the store still answers. The in-memory backend always does.
+func (b *Backend) Ping(ctx context.Context) error {
+	if b.ping == nil {
+		return nil
+	}
+	return b.ping(ctx)
+}
+
+func (b *Backend) Close() {
+	b.close()
+}
@@ -0,0 +1,141 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned without calling the store while the circuit is open.
+var ErrOpen error = openError{}
+
+type openError struct{}
+
+func (openError) Error() string { return "breaker: circuit open, store unavailable" }
+
+// ErrorClass puts ErrOpen under "unavailable" in the logs.
+func (openError) ErrorClass() string { return "unavailable" }
+
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+type Config struct {
+	// Threshold consecutive failures open the circuit. Zero disables it.
+	Threshold int
+	// Cooldown is how long the circuit stays open before one probe call is
+	// let through.
+	Cooldown time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Threshold: 5,
+		Cooldown:  10 * time.Second,
+	}
+}
+
+type BreakerOption func(*Breaker)
+
+func WithClock(now func() time.Time) BreakerOption {
+	return func(b *Breaker) {
+		b.now = now
+	}
+}
+
+// Breaker stops calls to a failing dependency so callers can fall back at
+// once instead of waiting for every request to time out.
+type Breaker struct {
+	cfg Config
+	now func() time.Time
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func New(cfg Config, opts ...BreakerOption) *Breaker {
+	b := &Breaker{cfg: cfg, now: time.Now}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Allow reports whether a call may proceed. Every allowed call must be
+// followed by Record with its outcome.
+func (b *Breaker) Allow() error {
+	if b.cfg.Threshold <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Open:
+		if b.now().Sub(b.openedAt) < b.cfg.Cooldown {
+			return ErrOpen
+		}
+		b.state = HalfOpen
+		b.probing = true
+		return nil
+
+	case HalfOpen:
+		if b.probing {
+			return ErrOpen
+		}
+		b.probing = true
+		return nil
+	}
+
+	return nil
+}
+
+// Record feeds the outcome of an allowed call. Cancellations by the caller
+// say nothing about the store and are ignored.
+func (b *Breaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if b.cfg.Threshold <= 0 || errors.Is(err, context.Canceled) {
+		return
+	}
+
+	if err == nil {
+		b.state = Closed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == HalfOpen || b.failures >= b.cfg.Threshold {
+		b.state = Open
+		b.openedAt = b.now()
+	}
+}
+
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
@@ -0,0 +1,52 @@
+package breaker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rgdevment/spam-registry/internal/platform/storage/breaker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreakerOpensAndProbes(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	b := breaker.New(breaker.Config{Threshold: 3, Cooldown: 10 * time.Second},
+		breaker.WithClock(func() time.Time { return now }))
+	failure := errors.New("timeout")
+
+	for range 3 {
+		require.NoError(t, b.Allow())
+		b.Record(failure)
+	}
+	assert.Equal(t, breaker.Open, b.State())
+	assert.ErrorIs(t, b.Allow(), breaker.ErrOpen)
+
+	now = now.Add(10 * time.Second)
+	require.NoError(t, b.Allow(), "one probe after the cooldown")
+	assert.Equal(t, breaker.HalfOpen, b.State())
+	assert.ErrorIs(t, b.Allow(), breaker.ErrOpen, "only one probe at a time")
+
+	b.Record(failure)
+	assert.Equal(t, breaker.Open, b.State(), "a failed probe reopens")
+
+	now = now.Add(10 * time.Second)
+	require.NoError(t, b.Allow())
+	b.Record(nil)
+	assert.Equal(t, breaker.Closed, b.State())
+	assert.NoError(t, b.Allow())
+}
+
+func TestBreakerIgnoresCallerCancellation(t *testing.T) {
+	b := breaker.New(breaker.Config{Threshold: 1, Cooldown: time.Minute})
+
+	require.NoError(t, b.Allow())
+	b.Record(context.Canceled)
+	assert.Equal(t, breaker.Closed, b.State())
+
+	require.NoError(t, b.Allow())
+	b.Record(context.DeadlineExceeded)
+	assert.Equal(t, breaker.Open, b.State())
+}
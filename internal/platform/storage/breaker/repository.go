@@ -0,0 +1,59 @@
+package breaker
+
+import (
+	"context"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+	"github.com/rgdevment/spam-registry/internal/service"
+)
+
+// repository guards the calls that have a fallback with a Breaker: lookups
+// (the blocklist snapshot) and report saves (the spool). Other writes must
+// surface their own error.
+type repository struct {
+	service.Repository
+	breaker *Breaker
+}
+
+func NewRepository(inner service.Repository, b *Breaker) service.Repository {
+	return &repository{
+		Repository: inner,
+		breaker:    b,
+	}
+}
+
+func (r *repository) SaveRawReport(ctx context.Context, report *domain.Report) error {
+	if err := r.breaker.Allow(); err != nil {
+		return err
+	}
+	err := r.Repository.SaveRawReport(ctx, report)
+	r.breaker.Record(err)
+	return err
+}
+
+func (r *repository) GetScore(ctx context.Context, phoneNumber string) (*domain.PhoneScore, error) {
+	if err := r.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	score, err := r.Repository.GetScore(ctx, phoneNumber)
+	r.breaker.Record(err)
+	return score, err
+}
+
+func (r *repository) GetScoresByHashPrefix(ctx context.Context, prefix string) ([]*domain.HashedScore, error) {
+	if err := r.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	matches, err := r.Repository.GetScoresByHashPrefix(ctx, prefix)
+	r.breaker.Record(err)
+	return matches, err
+}
+
+func (r *repository) ListCountryThreats(ctx context.Context, countryCode string) ([]*domain.PhoneScore, error) {
+	if err := r.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	threats, err := r.Repository.ListCountryThreats(ctx, countryCode)
+	r.breaker.Record(err)
+	return threats, err
+}
@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+)
+
+// Cache stores scores by phone number. LRU is the in-process implementation;
+// a shared backend (Redis, Memcached) lets every API replica, and the worker
+// that recalculates scores, see the same entries and invalidations.
+//
+// Errors are never fatal to a lookup: a failed Get is a miss and a failed Set
+// is skipped.
+type Cache interface {
+	Get(ctx context.Context, phoneNumber string) (*domain.PhoneScore, bool, error)
+	Set(ctx context.Context, phoneNumber string, score *domain.PhoneScore, ttl time.Duration) error
+	Delete(ctx context.Context, phoneNumber string) error
+}
+
+// Config sizes the score cache. A zero Size disables it.
+type Config struct {
+	Size int
+
+	// TTL bounds how long a score recalculated by another process can be
+	// served stale. NegativeTTL does the same for numbers without a score,
+	// which are most lookups and change far less often.
+	TTL         time.Duration
+	NegativeTTL time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Size:        100_000,
+		TTL:         30 * time.Second,
+		NegativeTTL: 2 * time.Minute,
+	}
+}
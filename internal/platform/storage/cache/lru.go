@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+)
+
+type LRUOption func(*LRU)
+
+func WithClock(now func() time.Time) LRUOption {
+	return func(c *LRU) {
+		c.now = now
+	}
+}
+
+// LRU is a size-bounded in-process Cache. Entries also expire after the TTL
+// they were set with.
+type LRU struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	entries  map[string]*list.Element
+	now      func() time.Time
+}
+
+type lruEntry struct {
+	phoneNumber string
+	score       domain.PhoneScore
+	expiresAt   time.Time
+}
+
+func NewLRU(capacity int, opts ...LRUOption) *LRU {
+	c := &LRU{
+		capacity: max(capacity, 1),
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *LRU) Get(ctx context.Context, phoneNumber string) (*domain.PhoneScore, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[phoneNumber]
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := elem.Value.(*lruEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.remove(elem)
+		return nil, false, nil
+	}
+
+	c.order.MoveToFront(elem)
+	score := entry.score
+	return &score, true, nil
+}
+
+func (c *LRU) Set(ctx context.Context, phoneNumber string, score *domain.PhoneScore, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(ttl)
+	if elem, ok := c.entries[phoneNumber]; ok {
+		entry := elem.Value.(*lruEntry)
+		entry.score = *score
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return nil
+	}
+
+	c.entries[phoneNumber] = c.order.PushFront(&lruEntry{
+		phoneNumber: phoneNumber,
+		score:       *score,
+		expiresAt:   expiresAt,
+	})
+	for c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+	}
+	return nil
+}
+
+func (c *LRU) Delete(ctx context.Context, phoneNumber string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[phoneNumber]; ok {
+		c.remove(elem)
+	}
+	return nil
+}
+
+// Len is the number of entries held, expired ones included until touched.
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *LRU) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*lruEntry).phoneNumber)
+}
@@ -0,0 +1,170 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+	"github.com/rgdevment/spam-registry/internal/platform/logging"
+	"github.com/rgdevment/spam-registry/internal/service"
+)
+
+type Option func(*Repository)
+
+func WithTTL(ttl time.Duration) Option {
+	return func(r *Repository) {
+		r.ttl = ttl
+	}
+}
+
+// WithNegativeTTL sets how long a number without a score is remembered.
+// Zero disables negative caching.
+func WithNegativeTTL(ttl time.Duration) Option {
+	return func(r *Repository) {
+		r.negativeTTL = ttl
+	}
+}
+
+// Repository serves GetScore through a Cache and invalidates the entry when
+// UpsertScore or DeleteScore change it. Everything else goes straight to the
+// wrapped repository.
+type Repository struct {
+	service.Repository
+	cache Cache
+
+	ttl         time.Duration
+	negativeTTL time.Duration
+
+	// epoch changes on every invalidation. A miss only fills the cache if no
+	// invalidation ran while it was reading, so a slow read cannot put back
+	// the score a concurrent write just replaced.
+	mu    sync.RWMutex
+	epoch uint64
+
+	hits         atomic.Uint64
+	negativeHits atomic.Uint64
+	misses       atomic.Uint64
+	errors       atomic.Uint64
+}
+
+func NewRepository(inner service.Repository, c Cache, opts ...Option) *Repository {
+	defaults := DefaultConfig()
+	r := &Repository{
+		Repository:  inner,
+		cache:       c,
+		ttl:         defaults.TTL,
+		negativeTTL: defaults.NegativeTTL,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *Repository) GetScore(ctx context.Context, phoneNumber string) (*domain.PhoneScore, error) {
+	score, ok, err := r.cache.Get(ctx, phoneNumber)
+	if err != nil {
+		r.errors.Add(1)
+	}
+	if ok {
+		r.hits.Add(1)
+		if unknown(score) {
+			r.negativeHits.Add(1)
+		}
+		return score, nil
+	}
+	r.misses.Add(1)
+
+	r.mu.RLock()
+	epoch := r.epoch
+	r.mu.RUnlock()
+
+	score, err = r.Repository.GetScore(ctx, phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := r.ttl
+	if unknown(score) {
+		ttl = r.negativeTTL
+	}
+	if ttl <= 0 {
+		return score, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.epoch == epoch {
+		cached := *score
+		if err := r.cache.Set(ctx, phoneNumber, &cached, ttl); err != nil {
+			r.errors.Add(1)
+		}
+	}
+
+	return score, nil
+}
+
+func (r *Repository) UpsertScore(ctx context.Context, s *domain.PhoneScore, ttlSeconds int) error {
+	if err := r.Repository.UpsertScore(ctx, s, ttlSeconds); err != nil {
+		return err
+	}
+	r.invalidate(ctx, s.PhoneNumber)
+	return nil
+}
+
+func (r *Repository) DeleteScore(ctx context.Context, phoneNumber string, countryCode string) error {
+	if err := r.Repository.DeleteScore(ctx, phoneNumber, countryCode); err != nil {
+		return err
+	}
+	r.invalidate(ctx, phoneNumber)
+	return nil
+}
+
+// invalidate drops the entry after a successful write. Failing to do so only
+// leaves the old score in place until its TTL, so it is logged, not returned.
+func (r *Repository) invalidate(ctx context.Context, phoneNumber string) {
+	r.mu.Lock()
+	r.epoch++
+	r.mu.Unlock()
+
+	if err := r.cache.Delete(ctx, phoneNumber); err != nil {
+		r.errors.Add(1)
+		slog.WarnContext(ctx, "⚠️  cache: failed to invalidate score",
+			logging.Phone("phone", phoneNumber), logging.Err(err))
+	}
+}
+
+// Stats counts GetScore outcomes since the repository was created.
+type Stats struct {
+	Hits         uint64
+	NegativeHits uint64
+	Misses       uint64
+	Errors       uint64
+}
+
+// HitRatio is the share of lookups answered from the cache, 0 before any.
+func (s Stats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+func (r *Repository) Stats() Stats {
+	return Stats{
+		Hits:         r.hits.Load(),
+		NegativeHits: r.negativeHits.Load(),
+		Misses:       r.misses.Load(),
+		Errors:       r.errors.Load(),
+	}
+}
+
+// unknown reports whether score is the SAFE placeholder returned for numbers
+// that were never scored.
+func unknown(score *domain.PhoneScore) bool {
+	return score.LastActivity.IsZero() && score.TotalReports == 0
+}
@@ -0,0 +1,125 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/cache"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/memory"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/storagetest"
+	"github.com/rgdevment/spam-registry/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositoryContract(t *testing.T) {
+	// The cache cannot see a score's own TTL, so it must stay below the
+	// shortest one the contract uses.
+	storagetest.RunRepositoryContract(t, func(t *testing.T, now func() time.Time) service.Repository {
+		return cache.NewRepository(
+			memory.NewMemoryRepository(memory.WithClock(now)),
+			cache.NewLRU(1000, cache.WithClock(now)),
+			cache.WithTTL(time.Second), cache.WithNegativeTTL(time.Second),
+		)
+	})
+}
+
+type countingRepo struct {
+	service.Repository
+	reads int
+}
+
+func (r *countingRepo) GetScore(ctx context.Context, phoneNumber string) (*domain.PhoneScore, error) {
+	r.reads++
+	return r.Repository.GetScore(ctx, phoneNumber)
+}
+
+func TestUnknownNumbersAreCachedNegatively(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingRepo{Repository: memory.NewMemoryRepository()}
+	repo := cache.NewRepository(inner, cache.NewLRU(10))
+
+	for range 5 {
+		score, err := repo.GetScore(ctx, "+56911111111")
+		require.NoError(t, err)
+		assert.Equal(t, domain.LevelSafe, score.RiskLevel)
+	}
+
+	assert.Equal(t, 1, inner.reads)
+	stats := repo.Stats()
+	assert.Equal(t, uint64(4), stats.Hits)
+	assert.Equal(t, uint64(4), stats.NegativeHits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.InDelta(t, 0.8, stats.HitRatio(), 1e-9)
+}
+
+func TestScoreWritesInvalidate(t *testing.T) {
+	ctx := context.Background()
+	phone := "+56922222222"
+	inner := &countingRepo{Repository: memory.NewMemoryRepository()}
+	repo := cache.NewRepository(inner, cache.NewLRU(10))
+
+	_, err := repo.GetScore(ctx, phone)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.UpsertScore(ctx, &domain.PhoneScore{
+		PhoneNumber: phone, CountryCode: "CL", Score: 80, RiskLevel: domain.LevelCritical,
+		LastActivity: time.Now().UTC(), TotalReports: 4,
+	}, 3600))
+
+	score, err := repo.GetScore(ctx, phone)
+	require.NoError(t, err)
+	assert.Equal(t, domain.LevelCritical, score.RiskLevel, "the negative entry must not outlive the upsert")
+
+	require.NoError(t, repo.DeleteScore(ctx, phone, "CL"))
+
+	score, err = repo.GetScore(ctx, phone)
+	require.NoError(t, err)
+	assert.Equal(t, domain.LevelSafe, score.RiskLevel)
+	assert.Equal(t, 3, inner.reads)
+}
+
+func TestCachedScoresAreCopies(t *testing.T) {
+	ctx := context.Background()
+	repo := cache.NewRepository(memory.NewMemoryRepository(), cache.NewLRU(10))
+
+	score, err := repo.GetScore(ctx, "+56933333333")
+	require.NoError(t, err)
+	score.RiskLevel = domain.LevelCritical
+
+	again, err := repo.GetScore(ctx, "+56933333333")
+	require.NoError(t, err)
+	assert.Equal(t, domain.LevelSafe, again.RiskLevel)
+}
+
+func TestLRUEvictsLeastRecentlyUsedAndExpires(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	lru := cache.NewLRU(2, cache.WithClock(func() time.Time { return now }))
+
+	set := func(phone string, ttl time.Duration) {
+		require.NoError(t, lru.Set(ctx, phone, &domain.PhoneScore{PhoneNumber: phone}, ttl))
+	}
+	has := func(phone string) bool {
+		_, ok, err := lru.Get(ctx, phone)
+		require.NoError(t, err)
+		return ok
+	}
+
+	set("a", time.Minute)
+	set("b", time.Minute)
+	assert.True(t, has("a"))
+	set("c", time.Minute)
+
+	assert.True(t, has("a"))
+	assert.False(t, has("b"), "b was the least recently used")
+	assert.True(t, has("c"))
+
+	set("a", time.Second)
+	now = now.Add(2 * time.Second)
+	assert.False(t, has("a"))
+	assert.True(t, has("c"))
+	assert.Equal(t, 1, lru.Len())
+}
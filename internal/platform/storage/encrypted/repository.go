@@ -0,0 +1,108 @@
+package encrypted
+
+import (
+	"context"
+	"errors"
+	"iter"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+	"github.com/rgdevment/spam-registry/internal/platform/crypto/envelope"
+	"github.com/rgdevment/spam-registry/internal/service"
+)
+
+// commentRepository encrypts report comments on the way into the wrapped
+// repository and decrypts them on the way out. Comments from a shredded
+// month are returned empty.
+type commentRepository struct {
+	service.Repository
+	enc *envelope.Encrypter
+}
+
+func NewCommentRepository(inner service.Repository, enc *envelope.Encrypter) service.Repository {
+	return &commentRepository{
+		Repository: inner,
+		enc:        enc,
+	}
+}
+
+func (r *commentRepository) SaveRawReport(ctx context.Context, report *domain.Report) error {
+	sealed, err := r.enc.Seal(ctx, report.CreatedAt, report.Comment, commentAAD(report))
+	if errors.Is(err, envelope.ErrDataKeyShredded) {
+		sealed, err = "", nil
+	}
+	if err != nil {
+		return err
+	}
+
+	stored := *report
+	stored.Comment = sealed
+	return r.Repository.SaveRawReport(ctx, &stored)
+}
+
+func (r *commentRepository) GetRawReports(ctx context.Context, phoneNumber string) ([]*domain.Report, error) {
+	reports, err := r.Repository.GetRawReports(ctx, phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, report := range reports {
+		if err := r.openComment(ctx, report); err != nil {
+			return nil, err
+		}
+	}
+
+	return reports, nil
+}
+
+func (r *commentRepository) StreamRawReports(ctx context.Context, phoneNumber string, rng service.ReportRange) iter.Seq2[*domain.Report, error] {
+	return func(yield func(*domain.Report, error) bool) {
+		for report, err := range r.Repository.StreamRawReports(ctx, phoneNumber, rng) {
+			if err == nil {
+				err = r.openComment(ctx, report)
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(report, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (r *commentRepository) openComment(ctx context.Context, report *domain.Report) error {
+	plain, err := commentSealer{r.enc}.OpenComment(ctx, report)
+	if err != nil {
+		return err
+	}
+	report.Comment = plain
+	return nil
+}
+
+// commentSealer seals comments exactly as commentRepository stores them, for
+// copies the service keeps outside the repository.
+type commentSealer struct {
+	enc *envelope.Encrypter
+}
+
+func NewCommentSealer(enc *envelope.Encrypter) service.CommentSealer {
+	return commentSealer{enc: enc}
+}
+
+func (c commentSealer) SealComment(ctx context.Context, report *domain.Report) (string, error) {
+	return c.enc.Seal(ctx, report.CreatedAt, report.Comment, commentAAD(report))
+}
+
+// OpenComment returns an empty comment once its month was shredded.
+func (c commentSealer) OpenComment(ctx context.Context, report *domain.Report) (string, error) {
+	plain, err := c.enc.Open(ctx, report.Comment, commentAAD(report))
+	if errors.Is(err, envelope.ErrDataKeyShredded) {
+		return "", nil
+	}
+	return plain, err
+}
+
+func commentAAD(report *domain.Report) []byte {
+	return []byte(report.PhoneNumber + "|" + report.ID.String())
+}
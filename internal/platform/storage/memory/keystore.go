@@ -0,0 +1,48 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/rgdevment/spam-registry/internal/platform/crypto/envelope"
+)
+
+// The Repository doubles as an envelope.KeyStore so comment encryption also
+// works without Scylla.
+
+func (r *Repository) GetDataKey(ctx context.Context, keyID string) ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.dataKeys[keyID]
+	if !ok {
+		return nil, envelope.ErrDataKeyNotFound
+	}
+	if stored.Shredded {
+		return nil, envelope.ErrDataKeyShredded
+	}
+
+	return stored.Wrapped, nil
+}
+
+func (r *Repository) CreateDataKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored, ok := r.dataKeys[keyID]; ok {
+		if stored.Shredded {
+			return nil, envelope.ErrDataKeyShredded
+		}
+		return stored.Wrapped, nil
+	}
+
+	r.dataKeys[keyID] = &storedDataKey{Wrapped: wrapped}
+	return wrapped, nil
+}
+
+func (r *Repository) ShredDataKey(ctx context.Context, keyID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.dataKeys[keyID] = &storedDataKey{Shredded: true}
+	return nil
+}
@@ -0,0 +1,471 @@
+package memory
+
+import (
+	"context"
+	"iter"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+	"github.com/rgdevment/spam-registry/internal/service"
+)
+
+// Same lifetime Scylla gives raw reports and queue entries.
+const rawReportTTL = 47304000 * time.Second
+
+// Same period as the sqlite sweeper.
+const defaultSweepInterval = 10 * time.Minute
+
+type storedReport struct {
+	Report    domain.Report
+	ExpiresAt time.Time
+}
+
+type storedScore struct {
+	Score     domain.PhoneScore
+	ExpiresAt time.Time
+}
+
+type storedModeration struct {
+	Item      domain.ModerationItem
+	ExpiresAt time.Time
+}
+
+type storedAggregate struct {
+	Aggregate domain.PhoneAggregate
+	ExpiresAt time.Time
+}
+
+type storedDataKey struct {
+	Wrapped  []byte
+	Shredded bool
+}
+
+// Repository is a concurrency-safe, in-process implementation of
+// service.Repository that mirrors the Scylla TTL semantics. State can be
+// persisted with SaveSnapshot and restored with LoadSnapshot. Close stops
+// the background sweep of expired entries.
+type Repository struct {
+	mu  sync.RWMutex
+	now func() time.Time
+
+	sweepEvery time.Duration
+	stop       chan struct{}
+	closeOnce  sync.Once
+
+	reports    map[string]map[int64]*storedReport // phone -> created_at (ns) -> report
+	scores     map[string]*storedScore
+	threats    map[string]map[string]*storedScore // country -> phone
+	moderation map[string][]*storedModeration     // country -> items
+	aggregates map[string]*storedAggregate
+	dataKeys   map[string]*storedDataKey
+}
+
+type Option func(*Repository)
+
+// WithClock replaces time.Now, so tests can move TTLs forward.
+func WithClock(now func() time.Time) Option {
+	return func(r *Repository) {
+		r.now = now
+	}
+}
+
+// WithSweepInterval sets how often expired entries are dropped. Zero turns
+// the sweep off; reads ignore expired entries either way.
+func WithSweepInterval(d time.Duration) Option {
+	return func(r *Repository) {
+		r.sweepEvery = d
+	}
+}
+
+func NewMemoryRepository(opts ...Option) *Repository {
+	r := &Repository{
+		now:        time.Now,
+		sweepEvery: defaultSweepInterval,
+		stop:       make(chan struct{}),
+		reports:    make(map[string]map[int64]*storedReport),
+		scores:     make(map[string]*storedScore),
+		threats:    make(map[string]map[string]*storedScore),
+		moderation: make(map[string][]*storedModeration),
+		aggregates: make(map[string]*storedAggregate),
+		dataKeys:   make(map[string]*storedDataKey),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.sweepEvery > 0 {
+		go r.runSweeper(r.sweepEvery)
+	}
+	return r
+}
+
+// Close stops the sweeper. The stored data stays readable.
+func (r *Repository) Close() {
+	r.closeOnce.Do(func() { close(r.stop) })
+}
+
+func (r *Repository) runSweeper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.mu.Lock()
+			r.sweep()
+			r.mu.Unlock()
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+func (r *Repository) SaveRawReport(ctx context.Context, report *domain.Report) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	byTime, ok := r.reports[report.PhoneNumber]
+	if !ok {
+		byTime = make(map[int64]*storedReport)
+		r.reports[report.PhoneNumber] = byTime
+	}
+
+	// Like the Scylla primary key, a report at the same instant replaces the previous one.
+	byTime[report.CreatedAt.UnixNano()] = &storedReport{
+		Report:    *report,
+		ExpiresAt: r.now().Add(rawReportTTL),
+	}
+
+	return nil
+}
+
+func (r *Repository) GetRawReports(ctx context.Context, phoneNumber string) ([]*domain.Report, error) {
+	return r.rawReports(phoneNumber, service.ReportRange{}), nil
+}
+
+// StreamRawReports copies the matching reports under the read lock and yields
+// them after releasing it, so the caller may write to the repository while
+// iterating. PageSize has no meaning here.
+func (r *Repository) StreamRawReports(ctx context.Context, phoneNumber string, rng service.ReportRange) iter.Seq2[*domain.Report, error] {
+	return func(yield func(*domain.Report, error) bool) {
+		for _, report := range r.rawReports(phoneNumber, rng) {
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(report, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (r *Repository) rawReports(phoneNumber string, rng service.ReportRange) []*domain.Report {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := r.now()
+	var reports []*domain.Report
+
+	for _, stored := range r.reports[phoneNumber] {
+		if !now.Before(stored.ExpiresAt) || !rng.Contains(stored.Report.CreatedAt) {
+			continue
+		}
+		report := stored.Report
+		reports = append(reports, &report)
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].CreatedAt.After(reports[j].CreatedAt)
+	})
+
+	return reports
+}
+
+func (r *Repository) UpdateReporterHash(ctx context.Context, report *domain.Report) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.reports[report.PhoneNumber][report.CreatedAt.UnixNano()]
+	if !ok || !r.now().Before(stored.ExpiresAt) {
+		return nil
+	}
+
+	stored.Report.ReporterHash = report.ReporterHash
+	stored.Report.ReporterKeyVersion = report.ReporterKeyVersion
+	stored.Report.ReporterPrevHash = report.ReporterPrevHash
+	stored.Report.ReporterPrevKeyVersion = report.ReporterPrevKeyVersion
+
+	return nil
+}
+
+func (r *Repository) UpsertScore(ctx context.Context, s *domain.PhoneScore, ttlSeconds int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.scores[s.PhoneNumber] = &storedScore{
+		Score:     *s,
+		ExpiresAt: r.now().Add(time.Duration(ttlSeconds) * time.Second),
+	}
+
+	return nil
+}
+
+func (r *Repository) UpsertCountryThreat(ctx context.Context, s *domain.PhoneScore, ttlSeconds int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if s.RiskLevel == domain.LevelSafe {
+		delete(r.threats[s.CountryCode], s.PhoneNumber)
+		return nil
+	}
+
+	byPhone, ok := r.threats[s.CountryCode]
+	if !ok {
+		byPhone = make(map[string]*storedScore)
+		r.threats[s.CountryCode] = byPhone
+	}
+
+	byPhone[s.PhoneNumber] = &storedScore{
+		Score:     *s,
+		ExpiresAt: r.now().Add(time.Duration(ttlSeconds) * time.Second),
+	}
+
+	return nil
+}
+
+func (r *Repository) ListCountryThreats(ctx context.Context, countryCode string) ([]*domain.PhoneScore, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := r.now()
+	var threats []*domain.PhoneScore
+
+	for _, stored := range r.threats[countryCode] {
+		if !now.Before(stored.ExpiresAt) {
+			continue
+		}
+		threat := stored.Score
+		threats = append(threats, &threat)
+	}
+
+	// Same order as the Scylla clustering key: risk level, then number.
+	sort.Slice(threats, func(i, j int) bool {
+		if threats[i].RiskLevel != threats[j].RiskLevel {
+			return threats[i].RiskLevel < threats[j].RiskLevel
+		}
+		return threats[i].PhoneNumber < threats[j].PhoneNumber
+	})
+
+	return threats, nil
+}
+
+func (r *Repository) DeleteScore(ctx context.Context, phoneNumber string, countryCode string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.scores, phoneNumber)
+	delete(r.threats[countryCode], phoneNumber)
+
+	return nil
+}
+
+func (r *Repository) GetScore(ctx context.Context, phoneNumber string) (*domain.PhoneScore, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.scores[phoneNumber]
+	if !ok || !r.now().Before(stored.ExpiresAt) {
+		return &domain.PhoneScore{
+			PhoneNumber: phoneNumber,
+			Score:       0,
+			RiskLevel:   domain.LevelSafe,
+		}, nil
+	}
+
+	score := stored.Score
+	return &score, nil
+}
+
+func (r *Repository) GetScoresByHashPrefix(ctx context.Context, prefix string) ([]*domain.HashedScore, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := r.now()
+	matches := []*domain.HashedScore{}
+
+	for phone, stored := range r.scores {
+		if !now.Before(stored.ExpiresAt) {
+			continue
+		}
+		if hash := domain.HashPhone(phone); strings.HasPrefix(hash, prefix) {
+			matches = append(matches, &domain.HashedScore{
+				PhoneHash: hash,
+				Score:     stored.Score.Score,
+				RiskLevel: stored.Score.RiskLevel,
+			})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].PhoneHash < matches[j].PhoneHash
+	})
+
+	return matches, nil
+}
+
+func (r *Repository) EnqueueModeration(ctx context.Context, item *domain.ModerationItem) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := &storedModeration{
+		Item:      *item,
+		ExpiresAt: r.now().Add(rawReportTTL),
+	}
+
+	// Like the other stores, an item with the same key replaces the first.
+	queue := r.moderation[item.CountryCode]
+	for i, existing := range queue {
+		if existing.Item.ReportID == item.ReportID && existing.Item.EnqueuedAt.Equal(item.EnqueuedAt) {
+			queue[i] = stored
+			return nil
+		}
+	}
+	r.moderation[item.CountryCode] = append(queue, stored)
+
+	return nil
+}
+
+func (r *Repository) ListModerationQueue(ctx context.Context, countryCode string, limit int) ([]*domain.ModerationItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := r.now()
+	var items []*domain.ModerationItem
+
+	for _, stored := range r.moderation[countryCode] {
+		if !now.Before(stored.ExpiresAt) {
+			continue
+		}
+		item := stored.Item
+		items = append(items, &item)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].EnqueuedAt.Before(items[j].EnqueuedAt)
+	})
+	if len(items) > limit {
+		items = items[:limit]
+	}
+
+	return items, nil
+}
+
+// GetAggregate returns an empty aggregate with Version 0 for unknown numbers.
+func (r *Repository) GetAggregate(ctx context.Context, phoneNumber string) (*domain.PhoneAggregate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.aggregates[phoneNumber]
+	if !ok || !r.now().Before(stored.ExpiresAt) {
+		return domain.NewPhoneAggregate(phoneNumber), nil
+	}
+
+	return cloneAggregate(&stored.Aggregate), nil
+}
+
+// CompareAndSwapAggregate stores agg only if the stored version still matches.
+func (r *Repository) CompareAndSwapAggregate(ctx context.Context, agg *domain.PhoneAggregate, ttlSeconds int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+
+	var version int64
+	if stored, ok := r.aggregates[agg.PhoneNumber]; ok && now.Before(stored.ExpiresAt) {
+		version = stored.Aggregate.Version
+	}
+	if version != agg.Version {
+		return false, nil
+	}
+
+	agg.Version++
+	r.aggregates[agg.PhoneNumber] = &storedAggregate{
+		Aggregate: *cloneAggregate(agg),
+		ExpiresAt: now.Add(time.Duration(ttlSeconds) * time.Second),
+	}
+
+	return true, nil
+}
+
+func cloneAggregate(agg *domain.PhoneAggregate) *domain.PhoneAggregate {
+	c := *agg
+	c.CategoryDecay = maps.Clone(agg.CategoryDecay)
+	c.AutoBlockDays = maps.Clone(agg.AutoBlockDays)
+	c.ReporterSketch = slices.Clone(agg.ReporterSketch)
+	if c.CategoryDecay == nil {
+		c.CategoryDecay = make(map[domain.RiskCategory]float64)
+	}
+	if c.AutoBlockDays == nil {
+		c.AutoBlockDays = make(map[string]int)
+	}
+	return &c
+}
+
+// sweep drops expired entries. Reads already ignore them; sweeping only
+// bounds memory and keeps snapshots small. The caller holds the write lock.
+func (r *Repository) sweep() {
+	now := r.now()
+
+	for phone, stored := range r.aggregates {
+		if !now.Before(stored.ExpiresAt) {
+			delete(r.aggregates, phone)
+		}
+	}
+
+	for phone, byTime := range r.reports {
+		for ts, stored := range byTime {
+			if !now.Before(stored.ExpiresAt) {
+				delete(byTime, ts)
+			}
+		}
+		if len(byTime) == 0 {
+			delete(r.reports, phone)
+		}
+	}
+
+	for phone, stored := range r.scores {
+		if !now.Before(stored.ExpiresAt) {
+			delete(r.scores, phone)
+		}
+	}
+
+	for country, byPhone := range r.threats {
+		for phone, stored := range byPhone {
+			if !now.Before(stored.ExpiresAt) {
+				delete(byPhone, phone)
+			}
+		}
+		if len(byPhone) == 0 {
+			delete(r.threats, country)
+		}
+	}
+
+	for country, items := range r.moderation {
+		kept := items[:0]
+		for _, stored := range items {
+			if now.Before(stored.ExpiresAt) {
+				kept = append(kept, stored)
+			}
+		}
+		if len(kept) == 0 {
+			delete(r.moderation, country)
+		} else {
+			r.moderation[country] = kept
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSweeperDropsExpiredEntriesUntilClosed(t *testing.T) {
+	var now atomic.Int64
+	now.Store(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC).UnixNano())
+	clock := func() time.Time { return time.Unix(0, now.Load()) }
+	ctx := context.Background()
+
+	repo := NewMemoryRepository(WithClock(clock), WithSweepInterval(time.Millisecond))
+	require.NoError(t, repo.UpsertScore(ctx, &domain.PhoneScore{
+		PhoneNumber: "+56987654321", CountryCode: "CL", Score: 42, RiskLevel: domain.LevelWarning,
+	}, 3600))
+
+	stored := func() int {
+		repo.mu.RLock()
+		defer repo.mu.RUnlock()
+		return len(repo.scores)
+	}
+	assert.Equal(t, 1, stored())
+
+	now.Add(int64(2 * time.Hour))
+	assert.Eventually(t, func() bool { return stored() == 0 }, time.Second, time.Millisecond,
+		"the sweeper runs without a snapshot")
+
+	repo.Close()
+	repo.Close()
+	require.NoError(t, repo.UpsertScore(ctx, &domain.PhoneScore{
+		PhoneNumber: "+56987654321", CountryCode: "CL", Score: 42, RiskLevel: domain.LevelWarning,
+	}, 1))
+	now.Add(int64(time.Hour))
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 1, stored(), "Close stops the sweeper")
+}
@@ -0,0 +1,54 @@
+package memory_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/memory"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/storagetest"
+	"github.com/rgdevment/spam-registry/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositoryContract(t *testing.T) {
+	storagetest.RunRepositoryContract(t, func(t *testing.T, now func() time.Time) service.Repository {
+		repo := memory.NewMemoryRepository(memory.WithClock(now))
+		t.Cleanup(repo.Close)
+		return repo
+	})
+}
+
+func TestSnapshotRoundTripKeepsExpiry(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "gsr.json")
+
+	repo := memory.NewMemoryRepository(memory.WithClock(clock))
+	require.NoError(t, repo.SaveRawReport(ctx, domain.NewReport("+56987654321", "CL", "h1", domain.RiskFraud, "hola")))
+	require.NoError(t, repo.UpsertScore(ctx, &domain.PhoneScore{
+		PhoneNumber: "+56987654321", CountryCode: "CL", Score: 42, RiskLevel: domain.LevelWarning,
+	}, 3600))
+	require.NoError(t, repo.SaveSnapshot(path))
+
+	restored := memory.NewMemoryRepository(memory.WithClock(clock))
+	require.NoError(t, restored.LoadSnapshot(path))
+
+	reports, err := restored.GetRawReports(ctx, "+56987654321")
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, "hola", reports[0].Comment)
+
+	score, err := restored.GetScore(ctx, "+56987654321")
+	require.NoError(t, err)
+	assert.Equal(t, 42.0, score.Score)
+
+	now = now.Add(2 * time.Hour)
+	score, err = restored.GetScore(ctx, "+56987654321")
+	require.NoError(t, err)
+	assert.Equal(t, domain.LevelSafe, score.RiskLevel, "score TTL must survive the snapshot")
+}
@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rgdevment/spam-registry/internal/platform/logging"
+)
+
+type snapshot struct {
+	TakenAt    time.Time                 `json:"taken_at"`
+	Reports    []*storedReport           `json:"reports"`
+	Scores     []*storedScore            `json:"scores"`
+	Threats    []*storedScore            `json:"threats"`
+	Moderation []*storedModeration       `json:"moderation"`
+	Aggregates []*storedAggregate        `json:"aggregates"`
+	DataKeys   map[string]*storedDataKey `json:"data_keys"`
+}
+
+// SaveSnapshot writes the live state to path atomically: a temp file is
+// fsynced and renamed over the previous snapshot.
+func (r *Repository) SaveSnapshot(path string) error {
+	r.mu.Lock()
+	r.sweep()
+
+	snap := snapshot{
+		TakenAt:  r.now().UTC(),
+		DataKeys: r.dataKeys,
+	}
+	for _, byTime := range r.reports {
+		for _, stored := range byTime {
+			snap.Reports = append(snap.Reports, stored)
+		}
+	}
+	for _, stored := range r.scores {
+		snap.Scores = append(snap.Scores, stored)
+	}
+	for _, byPhone := range r.threats {
+		for _, stored := range byPhone {
+			snap.Threats = append(snap.Threats, stored)
+		}
+	}
+	for _, items := range r.moderation {
+		snap.Moderation = append(snap.Moderation, items...)
+	}
+	for _, stored := range r.aggregates {
+		snap.Aggregates = append(snap.Aggregates, stored)
+	}
+
+	data, err := json.Marshal(snap)
+	r.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("memory: failed to encode snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("memory: failed to create snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("memory: failed to write snapshot: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("memory: failed to sync snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("memory: failed to close snapshot: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("memory: failed to replace snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// LoadSnapshot replaces the state with the snapshot at path. A missing file
+// is not an error: the repository simply starts empty.
+func (r *Repository) LoadSnapshot(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("memory: failed to read snapshot: %w", err)
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("memory: failed to decode snapshot: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.reports = make(map[string]map[int64]*storedReport)
+	r.scores = make(map[string]*storedScore)
+	r.threats = make(map[string]map[string]*storedScore)
+	r.moderation = make(map[string][]*storedModeration)
+	r.aggregates = make(map[string]*storedAggregate)
+	r.dataKeys = make(map[string]*storedDataKey)
+
+	for _, stored := range snap.Reports {
+		phone := stored.Report.PhoneNumber
+		if r.reports[phone] == nil {
+			r.reports[phone] = make(map[int64]*storedReport)
+		}
+		r.reports[phone][stored.Report.CreatedAt.UnixNano()] = stored
+	}
+	for _, stored := range snap.Scores {
+		r.scores[stored.Score.PhoneNumber] = stored
+	}
+	for _, stored := range snap.Threats {
+		country := stored.Score.CountryCode
+		if r.threats[country] == nil {
+			r.threats[country] = make(map[string]*storedScore)
+		}
+		r.threats[country][stored.Score.PhoneNumber] = stored
+	}
+	for _, stored := range snap.Moderation {
+		r.moderation[stored.Item.CountryCode] = append(r.moderation[stored.Item.CountryCode], stored)
+	}
+	for _, stored := range snap.Aggregates {
+		r.aggregates[stored.Aggregate.PhoneNumber] = stored
+	}
+	for keyID, stored := range snap.DataKeys {
+		r.dataKeys[keyID] = stored
+	}
+
+	r.sweep()
+	return nil
+}
+
+// RunSnapshots saves a snapshot every interval and once more when ctx is done.
+func (r *Repository) RunSnapshots(ctx context.Context, path string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.SaveSnapshot(path); err != nil {
+				slog.Warn("⚠️  memory: snapshot not saved", logging.Err(err))
+			}
+		case <-ctx.Done():
+			if err := r.SaveSnapshot(path); err != nil {
+				slog.Warn("⚠️  memory: snapshot not saved", logging.Err(err))
+			}
+			return
+		}
+	}
+}
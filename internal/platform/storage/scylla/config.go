@@ -0,0 +1,166 @@
+package scylla
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// Config describes how to reach a cluster. The zero value is not usable;
+// start from DefaultConfig.
+type Config struct {
+	Hosts    []string
+	Keyspace string
+
+	Username string
+	Password string
+
+	// TLS is enabled when any of the files is set. Client certificates need
+	// both CertFile and KeyFile.
+	TLSCAFile         string
+	TLSCertFile       string
+	TLSKeyFile        string
+	TLSSkipHostVerify bool
+
+	// LocalDC pins the driver to one datacenter: hosts elsewhere are never
+	// contacted, so a region keeps serving when the others are unreachable.
+	LocalDC string
+
+	// Replication is applied to the keyspace by `gsrctl migrate up`.
+	Replication Replication
+
+	ProtoVersion   int
+	Timeout        time.Duration
+	ConnectTimeout time.Duration
+
+	// LookupConsistency applies to the public read path (scores, hash prefix
+	// lookups, threat lists); WriteConsistency to writes and to reads that
+	// feed the scoring of fresh writes.
+	LookupConsistency gocql.Consistency
+	WriteConsistency  gocql.Consistency
+
+	RetryAttempts int
+
+	// SpeculativeAttempts extra coordinators are tried for lookups that have
+	// not answered after SpeculativeDelay. Zero disables it.
+	SpeculativeAttempts int
+	SpeculativeDelay    time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Hosts:               []string{"localhost"},
+		ProtoVersion:        4,
+		Timeout:             5 * time.Second,
+		ConnectTimeout:      5 * time.Second,
+		LookupConsistency:   gocql.LocalOne,
+		WriteConsistency:    gocql.LocalQuorum,
+		RetryAttempts:       3,
+		SpeculativeAttempts: 1,
+		SpeculativeDelay:    50 * time.Millisecond,
+	}
+}
+
+// NewCluster turns cfg into a gocql cluster configuration.
+func NewCluster(cfg Config) (*gocql.ClusterConfig, error) {
+	if len(cfg.Hosts) == 0 {
+		return nil, fmt.Errorf("scylla: at least one host is required")
+	}
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return nil, fmt.Errorf("scylla: TLS client certificate and key must be set together")
+	}
+	if cfg.LocalDC != "" {
+		for _, c := range []gocql.Consistency{cfg.LookupConsistency, cfg.WriteConsistency} {
+			if c != gocql.LocalOne && c != gocql.LocalQuorum {
+				return nil, fmt.Errorf("scylla: consistency %s would leave local DC %s, use LOCAL_ONE or LOCAL_QUORUM", c, cfg.LocalDC)
+			}
+		}
+	}
+
+	cluster := gocql.NewCluster(cfg.Hosts...)
+	cluster.Keyspace = cfg.Keyspace
+	cluster.Consistency = cfg.WriteConsistency
+	cluster.ProtoVersion = cfg.ProtoVersion
+	cluster.Timeout = cfg.Timeout
+	cluster.ConnectTimeout = cfg.ConnectTimeout
+
+	cluster.QueryObserver = queryTracer{}
+	cluster.BatchObserver = queryTracer{}
+
+	cluster.RetryPolicy = &gocql.ExponentialBackoffRetryPolicy{
+		NumRetries: cfg.RetryAttempts,
+		Min:        100 * time.Millisecond,
+		Max:        2 * time.Second,
+	}
+
+	if cfg.LocalDC != "" {
+		cluster.PoolConfig.HostSelectionPolicy = gocql.TokenAwareHostPolicy(
+			gocql.DCAwareRoundRobinPolicy(cfg.LocalDC), gocql.ShuffleReplicas())
+		cluster.HostFilter = gocql.DataCentreHostFilter(cfg.LocalDC)
+		cluster.SerialConsistency = gocql.LocalSerial
+	} else {
+		cluster.PoolConfig.HostSelectionPolicy = gocql.TokenAwareHostPolicy(
+			gocql.RoundRobinHostPolicy(), gocql.ShuffleReplicas())
+	}
+
+	if cfg.Username != "" {
+		cluster.Authenticator = gocql.PasswordAuthenticator{
+			Username: cfg.Username,
+			Password: cfg.Password,
+		}
+	}
+
+	if cfg.TLSCAFile != "" || cfg.TLSCertFile != "" {
+		cluster.SslOpts = &gocql.SslOptions{
+			CaPath:                 cfg.TLSCAFile,
+			CertPath:               cfg.TLSCertFile,
+			KeyPath:                cfg.TLSKeyFile,
+			EnableHostVerification: !cfg.TLSSkipHostVerify,
+		}
+	}
+
+	return cluster, nil
+}
+
+// NewSession connects using cfg.
+func NewSession(cfg Config) (*gocql.Session, error) {
+	cluster, err := NewCluster(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := cluster.CreateSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to scylla: %w", err)
+	}
+
+	slog.Info("✅ Connected to ScyllaDB", "keyspace", cfg.Keyspace, "local_dc", cfg.LocalDC)
+	return session, nil
+}
+
+// Ping fails when the session is closed or no node answers a local read.
+func Ping(ctx context.Context, session *gocql.Session) error {
+	if session.Closed() {
+		return errors.New("scylla: session closed")
+	}
+	var release string
+	err := session.Query(`SELECT release_version FROM system.local`).
+		Consistency(gocql.One).WithContext(ctx).Scan(&release)
+	if err != nil {
+		return fmt.Errorf("scylla: ping failed: %w", err)
+	}
+	return nil
+}
+
+// Connect opens a session with DefaultConfig against hosts.
+func Connect(keyspace string, hosts ...string) (*gocql.Session, error) {
+	cfg := DefaultConfig()
+	cfg.Keyspace = keyspace
+	cfg.Hosts = hosts
+
+	return NewSession(cfg)
+}
@@ -0,0 +1,52 @@
+package scylla_test
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/scylla"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClusterAppliesConfig(t *testing.T) {
+	cfg := scylla.DefaultConfig()
+	cfg.Hosts = []string{"10.0.0.1", "10.0.0.2"}
+	cfg.Keyspace = "gsr"
+	cfg.Username = "gsr"
+	cfg.Password = "secret"
+	cfg.TLSCAFile = "/etc/scylla/ca.pem"
+	cfg.LocalDC = "latam"
+
+	cluster, err := scylla.NewCluster(cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, cluster.Hosts)
+	assert.Equal(t, gocql.LocalQuorum, cluster.Consistency)
+	assert.Equal(t, gocql.LocalSerial, cluster.SerialConsistency)
+	assert.Equal(t, gocql.PasswordAuthenticator{Username: "gsr", Password: "secret"}, cluster.Authenticator)
+	require.NotNil(t, cluster.SslOpts)
+	assert.True(t, cluster.SslOpts.EnableHostVerification)
+	assert.NotNil(t, cluster.PoolConfig.HostSelectionPolicy)
+}
+
+func TestNewClusterRejectsHalfClientCertificate(t *testing.T) {
+	cfg := scylla.DefaultConfig()
+	cfg.TLSCertFile = "/etc/scylla/client.pem"
+
+	_, err := scylla.NewCluster(cfg)
+	assert.Error(t, err)
+}
+
+func TestNewClusterKeepsLocalDCOperationsLocal(t *testing.T) {
+	cfg := scylla.DefaultConfig()
+	cfg.LocalDC = "eu"
+
+	cluster, err := scylla.NewCluster(cfg)
+	require.NoError(t, err)
+	require.NotNil(t, cluster.HostFilter)
+
+	cfg.WriteConsistency = gocql.Quorum
+	_, err = scylla.NewCluster(cfg)
+	assert.Error(t, err, "QUORUM spans every DC")
+}
@@ -0,0 +1,74 @@
+package scylla
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/rgdevment/spam-registry/internal/platform/crypto/envelope"
+)
+
+type dataKeyStore struct {
+	session *gocql.Session
+}
+
+func NewDataKeyStore(session *gocql.Session) envelope.KeyStore {
+	return &dataKeyStore{
+		session: session,
+	}
+}
+
+func (s *dataKeyStore) GetDataKey(ctx context.Context, keyID string) ([]byte, error) {
+	var wrapped []byte
+	var shredded bool
+
+	err := s.session.Query(`SELECT wrapped_key, shredded FROM comment_keys WHERE key_id = ?`, keyID).
+		WithContext(ctx).Scan(&wrapped, &shredded)
+
+	if err == gocql.ErrNotFound {
+		return nil, envelope.ErrDataKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scylla: failed to get data key: %w", err)
+	}
+	if shredded {
+		return nil, envelope.ErrDataKeyShredded
+	}
+
+	return wrapped, nil
+}
+
+func (s *dataKeyStore) CreateDataKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	existing := make(map[string]interface{})
+
+	applied, err := s.session.Query(`
+        INSERT INTO comment_keys (key_id, wrapped_key, shredded, created_at)
+        VALUES (?, ?, false, ?) IF NOT EXISTS`,
+		keyID, wrapped, time.Now().UTC(),
+	).WithContext(ctx).MapScanCAS(existing)
+
+	if err != nil {
+		return nil, fmt.Errorf("scylla: failed to create data key: %w", err)
+	}
+	if applied {
+		return wrapped, nil
+	}
+
+	if shredded, _ := existing["shredded"].(bool); shredded {
+		return nil, envelope.ErrDataKeyShredded
+	}
+	current, _ := existing["wrapped_key"].([]byte)
+	return current, nil
+}
+
+func (s *dataKeyStore) ShredDataKey(ctx context.Context, keyID string) error {
+	err := s.session.Query(`UPDATE comment_keys SET wrapped_key = null, shredded = true WHERE key_id = ?`, keyID).
+		WithContext(ctx).Exec()
+
+	if err != nil {
+		return fmt.Errorf("scylla: failed to shred data key: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,231 @@
+package scylla
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+//go:embed migrations/*.cql
+var migrationFiles embed.FS
+
+// ErrSchemaOutdated is returned by VerifySchema when the keyspace is missing
+// migrations this binary depends on.
+var ErrSchemaOutdated = errors.New("scylla: schema is behind this binary, run `gsrctl migrate up`")
+
+type Migration struct {
+	Version    int
+	Name       string
+	Statements []string
+}
+
+type MigrationState struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrations returns the embedded migrations ordered by version.
+func Migrations() ([]Migration, error) {
+	names, err := fs.Glob(migrationFiles, "migrations/*.cql")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	migrations := make([]Migration, 0, len(names))
+	for _, name := range names {
+		base := strings.TrimPrefix(name, "migrations/")
+		version, err := strconv.Atoi(strings.SplitN(base, "_", 2)[0])
+		if err != nil {
+			return nil, fmt.Errorf("scylla: migration %s has no numeric prefix", base)
+		}
+
+		script, err := migrationFiles.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, Migration{
+			Version:    version,
+			Name:       base,
+			Statements: splitStatements(string(script)),
+		})
+	}
+
+	return migrations, nil
+}
+
+// ExpectedSchemaVersion is the highest migration embedded in this binary.
+func ExpectedSchemaVersion() int {
+	migrations, err := Migrations()
+	if err != nil || len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// Migrate applies every pending migration in order and returns the ones it
+// ran. Statements are idempotent, so a keyspace created by hand from the old
+// schema script is adopted on the first run instead of failing.
+func Migrate(ctx context.Context, session *gocql.Session) ([]Migration, error) {
+	err := session.Query(`CREATE TABLE IF NOT EXISTS schema_migrations (
+        version int PRIMARY KEY,
+        name text,
+        applied_at timestamp
+    )`).WithContext(ctx).Exec()
+	if err != nil {
+		return nil, fmt.Errorf("scylla: failed to create schema_migrations: %w", err)
+	}
+	if err := session.AwaitSchemaAgreement(ctx); err != nil {
+		return nil, err
+	}
+
+	states, err := MigrationStatus(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, state := range states {
+		if state.Applied {
+			continue
+		}
+
+		if err := applyMigration(ctx, session, state.Migration); err != nil {
+			return applied, err
+		}
+		applied = append(applied, state.Migration)
+	}
+
+	return applied, nil
+}
+
+func applyMigration(ctx context.Context, session *gocql.Session, m Migration) error {
+	for _, stmt := range m.Statements {
+		err := session.Query(stmt).WithContext(ctx).Exec()
+		if err != nil && !isExistingColumn(stmt, err) {
+			return fmt.Errorf("scylla: migration %s failed: %w", m.Name, err)
+		}
+		if err := session.AwaitSchemaAgreement(ctx); err != nil {
+			return fmt.Errorf("scylla: migration %s: %w", m.Name, err)
+		}
+	}
+
+	err := session.Query(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+		m.Version, m.Name, time.Now().UTC()).WithContext(ctx).Exec()
+	if err != nil {
+		return fmt.Errorf("scylla: failed to record migration %s: %w", m.Name, err)
+	}
+
+	return nil
+}
+
+// MigrationStatus lists every embedded migration and whether it has been
+// applied to the keyspace.
+func MigrationStatus(ctx context.Context, session *gocql.Session) ([]MigrationState, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := appliedMigrations(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]MigrationState, 0, len(migrations))
+	for _, m := range migrations {
+		at, ok := applied[m.Version]
+		states = append(states, MigrationState{Migration: m, Applied: ok, AppliedAt: at})
+	}
+
+	return states, nil
+}
+
+// SchemaVersion returns the highest applied migration, or 0 on a keyspace
+// that was never migrated.
+func SchemaVersion(ctx context.Context, session *gocql.Session) (int, error) {
+	applied, err := appliedMigrations(ctx, session)
+	if err != nil {
+		return 0, err
+	}
+
+	version := 0
+	for v := range applied {
+		version = max(version, v)
+	}
+	return version, nil
+}
+
+// VerifySchema fails when the keyspace is behind the migrations embedded in
+// this binary. A newer schema is accepted: migrations only add columns and
+// tables, so an older binary keeps working during a rolling deploy.
+func VerifySchema(ctx context.Context, session *gocql.Session) error {
+	current, err := SchemaVersion(ctx, session)
+	if err != nil {
+		return err
+	}
+
+	if expected := ExpectedSchemaVersion(); current < expected {
+		return fmt.Errorf("%w (have %d, want %d)", ErrSchemaOutdated, current, expected)
+	}
+
+	return nil
+}
+
+func appliedMigrations(ctx context.Context, session *gocql.Session) (map[int]time.Time, error) {
+	iter := session.Query(`SELECT version, applied_at FROM schema_migrations`).WithContext(ctx).Iter()
+
+	applied := make(map[int]time.Time)
+	var version int
+	var at time.Time
+	for iter.Scan(&version, &at) {
+		applied[version] = at
+	}
+
+	if err := iter.Close(); err != nil {
+		if strings.Contains(err.Error(), "unconfigured table") {
+			return applied, nil
+		}
+		return nil, fmt.Errorf("scylla: failed to read schema_migrations: %w", err)
+	}
+
+	return applied, nil
+}
+
+// isExistingColumn reports whether an ALTER TABLE ... ADD failed only because
+// the column is already there (keyspaces created from the old schema script).
+func isExistingColumn(stmt string, err error) bool {
+	if !strings.HasPrefix(strings.ToUpper(stmt), "ALTER TABLE") {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "conflicts with an existing column") || strings.Contains(msg, "already exists")
+}
+
+func splitStatements(script string) []string {
+	var lines []string
+	for _, line := range strings.Split(script, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	var statements []string
+	for _, stmt := range strings.Split(strings.Join(lines, "\n"), ";") {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			statements = append(statements, stmt)
+		}
+	}
+	return statements
+}
@@ -0,0 +1,34 @@
+package scylla
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedMigrationsAreContiguous(t *testing.T) {
+	migrations, err := Migrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	for i, m := range migrations {
+		assert.Equal(t, i+1, m.Version, "migration %s is out of sequence", m.Name)
+		assert.NotEmpty(t, m.Statements, "migration %s has no statements", m.Name)
+	}
+	assert.Equal(t, len(migrations), ExpectedSchemaVersion())
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- comment; with a semicolon
+ALTER TABLE reports ADD lang text;
+
+CREATE TABLE IF NOT EXISTS t (
+    id int PRIMARY KEY
+);
+`
+	assert.Equal(t, []string{
+		"ALTER TABLE reports ADD lang text",
+		"CREATE TABLE IF NOT EXISTS t (\n    id int PRIMARY KEY\n)",
+	}, splitStatements(script))
+}
@@ -3,7 +3,7 @@ package scylla
 import (
 	"context"
 	"fmt"
-	"log"
+	"iter"
 	"time"
 
 	"github.com/gocql/gocql"
@@ -12,50 +12,100 @@ import (
 	"github.com/rgdevment/spam-registry/internal/service"
 )
 
+const (
+	rawReportTTLSeconds = 47304000
+
+	defaultReportPageSize = 500
+)
+
 type scyllaRepository struct {
-	session *gocql.Session
+	session     *gocql.Session
+	lookupCL    gocql.Consistency
+	writeCL     gocql.Consistency
+	speculative gocql.SpeculativeExecutionPolicy
 }
 
-func NewScyllaRepository(session *gocql.Session) service.Repository {
-	return &scyllaRepository{
-		session: session,
+type Option func(*scyllaRepository)
+
+// WithConsistency sets the consistency of lookups and of writes (and the
+// reads scoring depends on).
+func WithConsistency(lookup, write gocql.Consistency) Option {
+	return func(r *scyllaRepository) {
+		r.lookupCL = lookup
+		r.writeCL = write
 	}
 }
 
-func Connect(keyspace string, hosts ...string) (*gocql.Session, error) {
-	cluster := gocql.NewCluster(hosts...)
-	cluster.Keyspace = keyspace
-	cluster.Consistency = gocql.Quorum
-	cluster.ProtoVersion = 4
-	cluster.Timeout = 5 * time.Second
-	cluster.ConnectTimeout = 5 * time.Second
+// WithSpeculativeLookups retries slow lookups on other replicas after delay.
+// Only lookups are speculated: they are idempotent single-partition reads.
+func WithSpeculativeLookups(attempts int, delay time.Duration) Option {
+	return func(r *scyllaRepository) {
+		if attempts <= 0 {
+			r.speculative = gocql.NonSpeculativeExecution{}
+			return
+		}
+		r.speculative = &gocql.SimpleSpeculativeExecution{NumAttempts: attempts, TimeoutDelay: delay}
+	}
+}
 
-	session, err := cluster.CreateSession()
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to scylla: %w", err)
+// WithConfig applies the per-operation settings of cfg.
+func WithConfig(cfg Config) Option {
+	return func(r *scyllaRepository) {
+		WithConsistency(cfg.LookupConsistency, cfg.WriteConsistency)(r)
+		WithSpeculativeLookups(cfg.SpeculativeAttempts, cfg.SpeculativeDelay)(r)
 	}
+}
 
-	log.Println("✅ Connected to ScyllaDB")
-	return session, nil
+func NewScyllaRepository(session *gocql.Session, opts ...Option) service.Repository {
+	r := &scyllaRepository{
+		session: session,
+	}
+	WithConfig(DefaultConfig())(r)
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *scyllaRepository) lookup(ctx context.Context, stmt string, values ...any) *gocql.Query {
+	return r.session.Query(stmt, values...).WithContext(ctx).
+		Consistency(r.lookupCL).
+		Idempotent(true).
+		SetSpeculativeExecutionPolicy(r.speculative)
+}
+
+func (r *scyllaRepository) query(ctx context.Context, stmt string, values ...any) *gocql.Query {
+	return r.session.Query(stmt, values...).WithContext(ctx).Consistency(r.writeCL)
+}
+
+func (r *scyllaRepository) batch(ctx context.Context) *gocql.Batch {
+	b := r.session.NewBatch(gocql.LoggedBatch).WithContext(ctx)
+	b.SetConsistency(r.writeCL)
+	return b
 }
 
 func (r *scyllaRepository) SaveRawReport(ctx context.Context, report *domain.Report) error {
 	query := `
-        INSERT INTO reports (id, phone_number, country_code, reporter_hash, category, comment, created_at)
-        VALUES (?, ?, ?, ?, ?, ?, ?) USING TTL ?`
+        INSERT INTO reports (id, phone_number, country_code, reporter_hash, reporter_key_version,
+                             reporter_prev_hash, reporter_prev_key_version, category, comment, created_at,
+                             moderation_status, lang)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) USING TTL ?`
 
-	const ttlSeconds = 47304000
-
-	err := r.session.Query(query,
+	err := r.query(ctx, query,
 		report.ID.String(),
 		report.PhoneNumber,
 		report.CountryCode,
 		report.ReporterHash,
+		report.ReporterKeyVersion,
+		report.ReporterPrevHash,
+		report.ReporterPrevKeyVersion,
 		string(report.Category),
 		report.Comment,
 		report.CreatedAt,
-		ttlSeconds,
-	).WithContext(ctx).Exec()
+		string(report.ModerationStatus),
+		report.Lang,
+		rawReportTTLSeconds,
+	).Exec()
 
 	if err != nil {
 		return fmt.Errorf("scylla: failed to save raw report: %w", err)
@@ -65,34 +115,102 @@ func (r *scyllaRepository) SaveRawReport(ctx context.Context, report *domain.Rep
 }
 
 func (r *scyllaRepository) GetRawReports(ctx context.Context, phoneNumber string) ([]*domain.Report, error) {
-	query := `SELECT id, phone_number, country_code, reporter_hash, category, comment, created_at 
-	          FROM reports WHERE phone_number = ?`
+	var reports []*domain.Report
+	for report, err := range r.StreamRawReports(ctx, phoneNumber, service.ReportRange{}) {
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
 
-	iter := r.session.Query(query, phoneNumber).WithContext(ctx).Iter()
+	return reports, nil
+}
 
-	var reports []*domain.Report
-	var id gocql.UUID
-	var phone, country, hash, catStr, comment string
-	var createdAt time.Time
+func (r *scyllaRepository) StreamRawReports(ctx context.Context, phoneNumber string, rng service.ReportRange) iter.Seq2[*domain.Report, error] {
+	return func(yield func(*domain.Report, error) bool) {
+		query := `SELECT id, phone_number, country_code, reporter_hash, reporter_key_version,
+		                 reporter_prev_hash, reporter_prev_key_version, category, comment, created_at,
+		                 moderation_status, lang
+		          FROM reports WHERE phone_number = ?`
+		values := []any{phoneNumber}
 
-	for iter.Scan(&id, &phone, &country, &hash, &catStr, &comment, &createdAt) {
-		parsedID, _ := uuid.Parse(id.String())
-		reports = append(reports, &domain.Report{
-			ID:           parsedID,
-			PhoneNumber:  phone,
-			CountryCode:  country,
-			ReporterHash: hash,
-			Category:     domain.RiskCategory(catStr),
-			Comment:      comment,
-			CreatedAt:    createdAt,
-		})
+		// created_at is the clustering key, so the bounds become a slice read.
+		if !rng.Since.IsZero() {
+			query += " AND created_at >= ?"
+			values = append(values, rng.Since)
+		}
+		if !rng.Until.IsZero() {
+			query += " AND created_at < ?"
+			values = append(values, rng.Until)
+		}
+
+		pageSize := rng.PageSize
+		if pageSize <= 0 {
+			pageSize = defaultReportPageSize
+		}
+
+		iter := r.query(ctx, query, values...).PageSize(pageSize).Iter()
+
+		var id gocql.UUID
+		var phone, country, hash, prevHash, catStr, comment, moderationStr, lang string
+		var keyVersion, prevKeyVersion int
+		var createdAt time.Time
+
+		for iter.Scan(&id, &phone, &country, &hash, &keyVersion, &prevHash, &prevKeyVersion, &catStr, &comment, &createdAt, &moderationStr, &lang) {
+			parsedID, _ := uuid.Parse(id.String())
+			report := &domain.Report{
+				ID:                     parsedID,
+				PhoneNumber:            phone,
+				CountryCode:            country,
+				ReporterHash:           hash,
+				ReporterKeyVersion:     keyVersion,
+				ReporterPrevHash:       prevHash,
+				ReporterPrevKeyVersion: prevKeyVersion,
+				Category:               domain.RiskCategory(catStr),
+				Comment:                comment,
+				CreatedAt:              createdAt,
+				ModerationStatus:       domain.ModerationStatus(moderationStr),
+				Lang:                   lang,
+			}
+			if !yield(report, nil) {
+				iter.Close()
+				return
+			}
+		}
+
+		if err := iter.Close(); err != nil {
+			yield(nil, fmt.Errorf("scylla: failed to iterate reports: %w", err))
+		}
 	}
+}
 
-	if err := iter.Close(); err != nil {
-		return nil, fmt.Errorf("scylla: failed to iterate reports: %w", err)
+func (r *scyllaRepository) UpdateReporterHash(ctx context.Context, report *domain.Report) error {
+	// Keep the row's original expiry instead of restarting the TTL.
+	ttl := rawReportTTLSeconds - int(time.Since(report.CreatedAt).Seconds())
+	if ttl <= 0 {
+		return nil
 	}
 
-	return reports, nil
+	query := `
+        UPDATE reports USING TTL ?
+        SET reporter_hash = ?, reporter_key_version = ?, reporter_prev_hash = ?, reporter_prev_key_version = ?
+        WHERE phone_number = ? AND created_at = ?`
+
+	err := r.query(ctx, query,
+		ttl,
+		report.ReporterHash,
+		report.ReporterKeyVersion,
+		report.ReporterPrevHash,
+		report.ReporterPrevKeyVersion,
+		report.PhoneNumber,
+		report.CreatedAt,
+	).Exec()
+
+	if err != nil {
+		return fmt.Errorf("scylla: failed to update reporter hash: %w", err)
+	}
+
+	return nil
 }
 
 func (r *scyllaRepository) GetScore(ctx context.Context, phoneNumber string) (*domain.PhoneScore, error) {
@@ -103,7 +221,7 @@ func (r *scyllaRepository) GetScore(ctx context.Context, phoneNumber string) (*d
 	var s domain.PhoneScore
 	var riskLevelStr string
 
-	err := r.session.Query(query, phoneNumber).WithContext(ctx).Scan(
+	err := r.lookup(ctx, query, phoneNumber).Scan(
 		&s.PhoneNumber,
 		&s.CountryCode,
 		&s.Score,
@@ -130,7 +248,10 @@ func (r *scyllaRepository) GetScore(ctx context.Context, phoneNumber string) (*d
 }
 
 func (r *scyllaRepository) UpsertScore(ctx context.Context, s *domain.PhoneScore, ttlSeconds int) error {
-	query := `
+	phoneHash := domain.HashPhone(s.PhoneNumber)
+
+	batch := r.batch(ctx)
+	batch.Query(`
         UPDATE scores USING TTL ?
         SET score = ?, 
             risk_level = ?, 
@@ -138,9 +259,7 @@ func (r *scyllaRepository) UpsertScore(ctx context.Context, s *domain.PhoneScore
             velocity_hit_count = ?, 
             total_reports = ?,
             country_code = ? 
-        WHERE phone_number = ?`
-
-	return r.session.Query(query,
+        WHERE phone_number = ?`,
 		ttlSeconds,
 		s.Score,
 		string(s.RiskLevel),
@@ -149,31 +268,256 @@ func (r *scyllaRepository) UpsertScore(ctx context.Context, s *domain.PhoneScore
 		s.TotalReports,
 		s.CountryCode,
 		s.PhoneNumber,
-	).WithContext(ctx).Exec()
+	)
+	batch.Query(`
+        INSERT INTO score_hash_index (hash_prefix, phone_hash, score, risk_level)
+        VALUES (?, ?, ?, ?) USING TTL ?`,
+		phoneHash[:domain.MinHashPrefixLength],
+		phoneHash,
+		s.Score,
+		string(s.RiskLevel),
+		ttlSeconds,
+	)
+
+	return r.session.ExecuteBatch(batch)
 }
 
+// A number is listed under one level at a time, so every write also clears
+// the rows of the other levels. SAFE numbers are only cleared.
+var threatLevels = []domain.RiskLevel{domain.LevelWarning, domain.LevelCritical}
+
 func (r *scyllaRepository) UpsertCountryThreat(ctx context.Context, s *domain.PhoneScore, ttlSeconds int) error {
-	if s.RiskLevel == domain.LevelSafe {
-		return nil
+	batch := r.batch(ctx)
+
+	for _, level := range threatLevels {
+		if level == s.RiskLevel {
+			continue
+		}
+		batch.Query("DELETE FROM active_threats WHERE country_code = ? AND risk_level = ? AND phone_number = ?",
+			s.CountryCode, string(level), s.PhoneNumber)
 	}
 
-	query := `
+	if s.RiskLevel != domain.LevelSafe {
+		batch.Query(`
         INSERT INTO active_threats (country_code, risk_level, phone_number, score, last_updated)
-        VALUES (?, ?, ?, ?, ?) USING TTL ?`
+        VALUES (?, ?, ?, ?, ?) USING TTL ?`,
+			s.CountryCode,
+			string(s.RiskLevel),
+			s.PhoneNumber,
+			s.Score,
+			s.LastActivity,
+			ttlSeconds,
+		)
+	}
 
-	return r.session.Query(query,
-		s.CountryCode,
-		string(s.RiskLevel),
-		s.PhoneNumber,
-		s.Score,
-		s.LastActivity,
-		ttlSeconds,
-	).WithContext(ctx).Exec()
+	return r.session.ExecuteBatch(batch)
+}
+
+func (r *scyllaRepository) ListCountryThreats(ctx context.Context, countryCode string) ([]*domain.PhoneScore, error) {
+	query := `SELECT risk_level, phone_number, score, last_updated FROM active_threats WHERE country_code = ?`
+
+	iter := r.lookup(ctx, query, countryCode).Iter()
+
+	var threats []*domain.PhoneScore
+	var levelStr, phone string
+	var score float64
+	var lastUpdated time.Time
+
+	for iter.Scan(&levelStr, &phone, &score, &lastUpdated) {
+		threats = append(threats, &domain.PhoneScore{
+			PhoneNumber:  phone,
+			CountryCode:  countryCode,
+			Score:        score,
+			RiskLevel:    domain.RiskLevel(levelStr),
+			LastActivity: lastUpdated,
+		})
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("scylla: failed to iterate active threats: %w", err)
+	}
+
+	return threats, nil
 }
 
 func (r *scyllaRepository) DeleteScore(ctx context.Context, phoneNumber string, countryCode string) error {
-	batch := r.session.NewBatch(gocql.LoggedBatch)
+	phoneHash := domain.HashPhone(phoneNumber)
+
+	batch := r.batch(ctx)
 	batch.Query("DELETE FROM scores WHERE phone_number = ?", phoneNumber)
+	batch.Query("DELETE FROM score_hash_index WHERE hash_prefix = ? AND phone_hash = ?",
+		phoneHash[:domain.MinHashPrefixLength], phoneHash)
+	for _, level := range threatLevels {
+		batch.Query("DELETE FROM active_threats WHERE country_code = ? AND risk_level = ? AND phone_number = ?",
+			countryCode, string(level), phoneNumber)
+	}
 
 	return r.session.ExecuteBatch(batch)
 }
+
+func (r *scyllaRepository) GetScoresByHashPrefix(ctx context.Context, prefix string) ([]*domain.HashedScore, error) {
+	// The partition holds the shortest allowed prefix; longer prefixes narrow the
+	// clustering range. "g" sorts after every lowercase hex digit.
+	query := `SELECT phone_hash, score, risk_level FROM score_hash_index
+	          WHERE hash_prefix = ? AND phone_hash >= ? AND phone_hash < ?`
+
+	iter := r.lookup(ctx, query, prefix[:domain.MinHashPrefixLength], prefix, prefix+"g").Iter()
+
+	matches := []*domain.HashedScore{}
+	var hash, levelStr string
+	var score float64
+
+	for iter.Scan(&hash, &score, &levelStr) {
+		matches = append(matches, &domain.HashedScore{
+			PhoneHash: hash,
+			Score:     score,
+			RiskLevel: domain.RiskLevel(levelStr),
+		})
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("scylla: failed to read hash prefix index: %w", err)
+	}
+
+	return matches, nil
+}
+
+func (r *scyllaRepository) EnqueueModeration(ctx context.Context, item *domain.ModerationItem) error {
+	flags := make([]string, 0, len(item.Flags))
+	for _, f := range item.Flags {
+		flags = append(flags, string(f))
+	}
+
+	query := `
+        INSERT INTO moderation_queue (country_code, enqueued_at, report_id, phone_number, report_created_at, flags, trace_context)
+        VALUES (?, ?, ?, ?, ?, ?, ?) USING TTL ?`
+
+	err := r.query(ctx, query,
+		item.CountryCode,
+		item.EnqueuedAt,
+		item.ReportID.String(),
+		item.PhoneNumber,
+		item.ReportCreatedAt,
+		flags,
+		item.TraceContext,
+		rawReportTTLSeconds,
+	).Exec()
+
+	if err != nil {
+		return fmt.Errorf("scylla: failed to enqueue moderation item: %w", err)
+	}
+
+	return nil
+}
+
+func (r *scyllaRepository) ListModerationQueue(ctx context.Context, countryCode string, limit int) ([]*domain.ModerationItem, error) {
+	query := `SELECT report_id, phone_number, report_created_at, flags, enqueued_at, trace_context
+	          FROM moderation_queue WHERE country_code = ? LIMIT ?`
+
+	iter := r.query(ctx, query, countryCode, limit).Iter()
+
+	var items []*domain.ModerationItem
+	var id gocql.UUID
+	var phone string
+	var flags []string
+	var reportCreatedAt, enqueuedAt time.Time
+	var traceContext map[string]string
+
+	for iter.Scan(&id, &phone, &reportCreatedAt, &flags, &enqueuedAt, &traceContext) {
+		parsedID, _ := uuid.Parse(id.String())
+		item := &domain.ModerationItem{
+			ReportID:        parsedID,
+			PhoneNumber:     phone,
+			CountryCode:     countryCode,
+			ReportCreatedAt: reportCreatedAt,
+			EnqueuedAt:      enqueuedAt,
+			TraceContext:    traceContext,
+		}
+		for _, f := range flags {
+			item.Flags = append(item.Flags, domain.ModerationFlag(f))
+		}
+		items = append(items, item)
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("scylla: failed to iterate moderation queue: %w", err)
+	}
+
+	return items, nil
+}
+
+func (r *scyllaRepository) GetAggregate(ctx context.Context, phoneNumber string) (*domain.PhoneAggregate, error) {
+	query := `
+        SELECT country_code, category_decay, decayed_at, reporter_sketch, auto_block_days,
+               last_human_activity, total_reports, version
+        FROM phone_aggregates WHERE phone_number = ?`
+
+	agg := domain.NewPhoneAggregate(phoneNumber)
+	var categoryDecay map[string]float64
+
+	// Read at the write consistency: the version feeds a compare-and-swap.
+	err := r.query(ctx, query, phoneNumber).Scan(
+		&agg.CountryCode,
+		&categoryDecay,
+		&agg.DecayedAt,
+		&agg.ReporterSketch,
+		&agg.AutoBlockDays,
+		&agg.LastHumanActivity,
+		&agg.TotalReports,
+		&agg.Version,
+	)
+
+	if err == gocql.ErrNotFound {
+		return domain.NewPhoneAggregate(phoneNumber), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scylla: failed to get aggregate: %w", err)
+	}
+
+	for cat, sum := range categoryDecay {
+		agg.CategoryDecay[domain.RiskCategory(cat)] = sum
+	}
+	if agg.AutoBlockDays == nil {
+		agg.AutoBlockDays = make(map[string]int)
+	}
+
+	return agg, nil
+}
+
+// CompareAndSwapAggregate uses a lightweight transaction on the version
+// column. An expired row reads as absent, so version 0 may recreate it.
+func (r *scyllaRepository) CompareAndSwapAggregate(ctx context.Context, agg *domain.PhoneAggregate, ttlSeconds int) (bool, error) {
+	categoryDecay := make(map[string]float64, len(agg.CategoryDecay))
+	for cat, sum := range agg.CategoryDecay {
+		categoryDecay[string(cat)] = sum
+	}
+
+	var query *gocql.Query
+	if agg.Version == 0 {
+		query = r.query(ctx, `
+        INSERT INTO phone_aggregates (phone_number, country_code, category_decay, decayed_at, reporter_sketch,
+                                      auto_block_days, last_human_activity, total_reports, version)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) IF NOT EXISTS USING TTL ?`,
+			agg.PhoneNumber, agg.CountryCode, categoryDecay, agg.DecayedAt, agg.ReporterSketch,
+			agg.AutoBlockDays, agg.LastHumanActivity, agg.TotalReports, agg.Version+1, ttlSeconds)
+	} else {
+		query = r.query(ctx, `
+        UPDATE phone_aggregates USING TTL ?
+        SET country_code = ?, category_decay = ?, decayed_at = ?, reporter_sketch = ?, auto_block_days = ?,
+            last_human_activity = ?, total_reports = ?, version = ?
+        WHERE phone_number = ? IF version = ?`,
+			ttlSeconds, agg.CountryCode, categoryDecay, agg.DecayedAt, agg.ReporterSketch, agg.AutoBlockDays,
+			agg.LastHumanActivity, agg.TotalReports, agg.Version+1, agg.PhoneNumber, agg.Version)
+	}
+
+	applied, err := query.MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		return false, fmt.Errorf("scylla: failed to store aggregate: %w", err)
+	}
+	if !applied {
+		return false, nil
+	}
+
+	agg.Version++
+	return true, nil
+}
@@ -0,0 +1,22 @@
+package scylla
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+// The contract test needs a live cluster; batch is checked without one.
+func TestBatchIsLoggedAtWriteConsistency(t *testing.T) {
+	r := NewScyllaRepository(&gocql.Session{}, WithConsistency(gocql.LocalOne, gocql.EachQuorum)).(*scyllaRepository)
+
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "batch")
+	b := r.batch(ctx)
+
+	assert.Equal(t, gocql.LoggedBatch, b.Type)
+	assert.Equal(t, gocql.EachQuorum, b.GetConsistency())
+	assert.Equal(t, ctx, b.Context())
+}
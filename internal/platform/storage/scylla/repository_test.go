@@ -0,0 +1,48 @@
+package scylla_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rgdevment/spam-registry/internal/platform/storage/scylla"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/storagetest"
+	"github.com/rgdevment/spam-registry/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+// Runs against a live cluster, migrating the keyspace first, e.g.
+// GSR_TEST_SCYLLA_HOST=localhost go test ./internal/platform/storage/scylla/
+func TestRepositoryContract(t *testing.T) {
+	host := os.Getenv("GSR_TEST_SCYLLA_HOST")
+	if host == "" {
+		t.Skip("GSR_TEST_SCYLLA_HOST not set")
+	}
+
+	keyspace := os.Getenv("GSR_TEST_SCYLLA_KEYSPACE")
+	if keyspace == "" {
+		keyspace = "gsr"
+	}
+
+	ctx := context.Background()
+
+	bootstrap, err := scylla.Connect("", host)
+	require.NoError(t, err)
+	_, err = scylla.EnsureKeyspace(ctx, bootstrap, keyspace, nil)
+	require.NoError(t, err)
+	bootstrap.Close()
+
+	session, err := scylla.Connect(keyspace, host)
+	require.NoError(t, err)
+	t.Cleanup(session.Close)
+
+	_, err = scylla.Migrate(ctx, session)
+	require.NoError(t, err)
+	require.NoError(t, scylla.VerifySchema(ctx, session))
+
+	// TTLs are enforced server side, so the contract waits on the wall clock.
+	storagetest.RunRepositoryContract(t, func(t *testing.T, now func() time.Time) service.Repository {
+		return scylla.NewScyllaRepository(session)
+	}, storagetest.RealTime())
+}
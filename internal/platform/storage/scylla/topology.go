@@ -0,0 +1,193 @@
+package scylla
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gocql/gocql"
+)
+
+var keyspaceName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,47}$`)
+
+// Replication maps each datacenter to its replication factor. An empty
+// Replication is the single-node development layout (SimpleStrategy, RF=1).
+type Replication map[string]int
+
+// ParseReplication reads "latam:3,eu:3".
+func ParseReplication(spec string) (Replication, error) {
+	replication := Replication{}
+
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		dc, rf, ok := strings.Cut(pair, ":")
+		factor, err := strconv.Atoi(strings.TrimSpace(rf))
+		if !ok || strings.TrimSpace(dc) == "" || err != nil || factor < 1 {
+			return nil, fmt.Errorf("scylla: invalid replication entry %q, expected dc:factor", pair)
+		}
+		replication[strings.TrimSpace(dc)] = factor
+	}
+
+	return replication, nil
+}
+
+// String renders the CQL replication map.
+func (r Replication) String() string {
+	if len(r) == 0 {
+		return "{'class': 'SimpleStrategy', 'replication_factor': 1}"
+	}
+
+	dcs := make([]string, 0, len(r))
+	for dc := range r {
+		dcs = append(dcs, dc)
+	}
+	sort.Strings(dcs)
+
+	parts := []string{"'class': 'NetworkTopologyStrategy'"}
+	for _, dc := range dcs {
+		parts = append(parts, fmt.Sprintf("'%s': %d", strings.ReplaceAll(dc, "'", "''"), r[dc]))
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+// EnsureKeyspace creates the keyspace with the given replication, or alters
+// an existing one whose replication differs. altered reports the latter:
+// data only moves to new replicas after a `nodetool repair`. An empty
+// replication never alters an existing keyspace. The session must not be
+// bound to a keyspace.
+func EnsureKeyspace(ctx context.Context, session *gocql.Session, keyspace string, replication Replication) (altered bool, err error) {
+	if !keyspaceName.MatchString(keyspace) {
+		return false, fmt.Errorf("scylla: invalid keyspace name %q", keyspace)
+	}
+
+	current, err := keyspaceReplication(session, keyspace)
+	switch {
+	case errors.Is(err, gocql.ErrKeyspaceDoesNotExist):
+		query := fmt.Sprintf("CREATE KEYSPACE IF NOT EXISTS %s WITH replication = %s", keyspace, replication)
+		if err := session.Query(query).WithContext(ctx).Exec(); err != nil {
+			return false, fmt.Errorf("scylla: failed to create keyspace %s: %w", keyspace, err)
+		}
+		return false, session.AwaitSchemaAgreement(ctx)
+
+	case err != nil:
+		return false, err
+
+	case len(replication) == 0 || sameReplication(current, replication):
+		return false, nil
+	}
+
+	query := fmt.Sprintf("ALTER KEYSPACE %s WITH replication = %s", keyspace, replication)
+	if err := session.Query(query).WithContext(ctx).Exec(); err != nil {
+		return false, fmt.Errorf("scylla: failed to alter keyspace %s: %w", keyspace, err)
+	}
+	return true, session.AwaitSchemaAgreement(ctx)
+}
+
+// keyspaceReplication returns the per-DC factors of a NetworkTopologyStrategy
+// keyspace; other strategies come back empty.
+func keyspaceReplication(session *gocql.Session, keyspace string) (Replication, error) {
+	meta, err := session.KeyspaceMetadata(keyspace)
+	if err != nil {
+		return nil, err
+	}
+
+	replication := Replication{}
+	if !strings.HasSuffix(meta.StrategyClass, "NetworkTopologyStrategy") {
+		return replication, nil
+	}
+
+	for dc, value := range meta.StrategyOptions {
+		if factor, err := strconv.Atoi(fmt.Sprint(value)); err == nil {
+			replication[dc] = factor
+		}
+	}
+	return replication, nil
+}
+
+func sameReplication(a, b Replication) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for dc, rf := range a {
+		if b[dc] != rf {
+			return false
+		}
+	}
+	return true
+}
+
+type NodeHealth struct {
+	Address string
+	Up      bool
+}
+
+// DCHealth summarises one datacenter as seen by the coordinator's gossip.
+type DCHealth struct {
+	Name              string
+	ReplicationFactor int
+	Nodes             []NodeHealth
+	Up                int
+
+	// LocalQuorum is whether LOCAL_QUORUM operations can still succeed in the
+	// DC for every token range, assuming evenly spread replicas.
+	LocalQuorum bool
+}
+
+// ReplicaHealth lists every datacenter of the cluster with its node states
+// and whether it can still serve keyspace at LOCAL_QUORUM. It relies on
+// Scylla's system.cluster_status virtual table.
+func ReplicaHealth(ctx context.Context, session *gocql.Session, keyspace string) ([]DCHealth, error) {
+	replication, err := keyspaceReplication(session, keyspace)
+	if err != nil {
+		return nil, fmt.Errorf("scylla: failed to read replication of %s: %w", keyspace, err)
+	}
+
+	iter := session.Query(`SELECT peer, dc, up FROM system.cluster_status`).
+		WithContext(ctx).Consistency(gocql.One).Iter()
+
+	byDC := map[string]*DCHealth{}
+	var peer, dc string
+	var up bool
+	for iter.Scan(&peer, &dc, &up) {
+		h, ok := byDC[dc]
+		if !ok {
+			h = &DCHealth{Name: dc, ReplicationFactor: replication[dc]}
+			byDC[dc] = h
+		}
+		h.Nodes = append(h.Nodes, NodeHealth{Address: peer, Up: up})
+		if up {
+			h.Up++
+		}
+	}
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("scylla: failed to read cluster status: %w", err)
+	}
+
+	health := make([]DCHealth, 0, len(byDC))
+	for _, h := range byDC {
+		sort.Slice(h.Nodes, func(i, j int) bool { return h.Nodes[i].Address < h.Nodes[j].Address })
+		h.LocalQuorum = localQuorumAvailable(h.ReplicationFactor, len(h.Nodes), h.Up)
+		health = append(health, *h)
+	}
+	sort.Slice(health, func(i, j int) bool { return health[i].Name < health[j].Name })
+
+	return health, nil
+}
+
+// localQuorumAvailable is conservative: any down node may hold a replica of
+// some range, so each one counts against the quorum.
+func localQuorumAvailable(rf, nodes, up int) bool {
+	if rf == 0 {
+		return up == nodes && up > 0
+	}
+	down := nodes - up
+	return rf-down >= rf/2+1
+}
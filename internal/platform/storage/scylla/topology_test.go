@@ -0,0 +1,31 @@
+package scylla
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReplication(t *testing.T) {
+	replication, err := ParseReplication("latam:3, eu:2")
+	require.NoError(t, err)
+	assert.Equal(t, Replication{"latam": 3, "eu": 2}, replication)
+	assert.Equal(t, "{'class': 'NetworkTopologyStrategy', 'eu': 2, 'latam': 3}", replication.String())
+
+	empty, err := ParseReplication("")
+	require.NoError(t, err)
+	assert.Equal(t, "{'class': 'SimpleStrategy', 'replication_factor': 1}", empty.String())
+
+	for _, bad := range []string{"latam", "latam:0", ":3", "latam:x"} {
+		_, err := ParseReplication(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestLocalQuorumAvailable(t *testing.T) {
+	assert.True(t, localQuorumAvailable(3, 3, 3))
+	assert.True(t, localQuorumAvailable(3, 5, 4))
+	assert.False(t, localQuorumAvailable(3, 5, 3))
+	assert.False(t, localQuorumAvailable(1, 1, 0))
+}
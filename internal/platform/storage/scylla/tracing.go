@@ -0,0 +1,64 @@
+package scylla
+
+import (
+	"context"
+	"time"
+
+	"github.com/gocql/gocql"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentation = "github.com/rgdevment/spam-registry/internal/platform/storage/scylla"
+
+// queryTracer turns every query attempt into a span under the caller's.
+// Bound values are never recorded: they hold phone numbers. Queries without
+// a parent span (driver housekeeping, migrations) are skipped.
+type queryTracer struct{}
+
+func (queryTracer) ObserveQuery(ctx context.Context, q gocql.ObservedQuery) {
+	record(ctx, "scylla.query", q.Start, q.End, q.Err,
+		attribute.String("db.query.text", q.Statement),
+		attribute.String("db.namespace", q.Keyspace),
+		attribute.String("server.address", hostAddress(q.Host)),
+		attribute.Int("gsr.db.attempt", q.Attempt),
+		attribute.Int("db.response.returned_rows", q.Rows),
+	)
+}
+
+func (queryTracer) ObserveBatch(ctx context.Context, b gocql.ObservedBatch) {
+	record(ctx, "scylla.batch", b.Start, b.End, b.Err,
+		attribute.StringSlice("db.query.text", b.Statements),
+		attribute.String("db.namespace", b.Keyspace),
+		attribute.String("server.address", hostAddress(b.Host)),
+		attribute.Int("gsr.db.attempt", b.Attempt),
+		attribute.Int("db.operation.batch.size", len(b.Statements)),
+	)
+}
+
+func record(ctx context.Context, name string, start, end time.Time, err error, attrs ...attribute.KeyValue) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return
+	}
+
+	attrs = append(attrs, attribute.String("db.system.name", "scylladb"))
+	_, span := otel.Tracer(instrumentation).Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(start),
+		trace.WithAttributes(attrs...),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End(trace.WithTimestamp(end))
+}
+
+func hostAddress(host *gocql.HostInfo) string {
+	if host == nil {
+		return ""
+	}
+	return host.ConnectAddress().String()
+}
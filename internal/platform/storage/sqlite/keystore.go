@@ -0,0 +1,68 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rgdevment/spam-registry/internal/platform/crypto/envelope"
+)
+
+type dataKeyStore struct {
+	db *sql.DB
+}
+
+func NewDataKeyStore(db *sql.DB) envelope.KeyStore {
+	return &dataKeyStore{
+		db: db,
+	}
+}
+
+func (s *dataKeyStore) GetDataKey(ctx context.Context, keyID string) ([]byte, error) {
+	var wrapped []byte
+	var shredded bool
+
+	err := s.db.QueryRowContext(ctx, `SELECT wrapped_key, shredded FROM comment_keys WHERE key_id = ?`, keyID).
+		Scan(&wrapped, &shredded)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, envelope.ErrDataKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: failed to get data key: %w", err)
+	}
+	if shredded {
+		return nil, envelope.ErrDataKeyShredded
+	}
+
+	return wrapped, nil
+}
+
+func (s *dataKeyStore) CreateDataKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	_, err := s.db.ExecContext(ctx, `
+        INSERT INTO comment_keys (key_id, wrapped_key, shredded, created_at)
+        VALUES (?, ?, 0, ?) ON CONFLICT (key_id) DO NOTHING`,
+		keyID, wrapped, time.Now().UTC().UnixNano(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: failed to create data key: %w", err)
+	}
+
+	return s.GetDataKey(ctx, keyID)
+}
+
+func (s *dataKeyStore) ShredDataKey(ctx context.Context, keyID string) error {
+	_, err := s.db.ExecContext(ctx, `
+        INSERT INTO comment_keys (key_id, wrapped_key, shredded, created_at)
+        VALUES (?, NULL, 1, ?)
+        ON CONFLICT (key_id) DO UPDATE SET wrapped_key = NULL, shredded = 1`,
+		keyID, time.Now().UTC().UnixNano(),
+	)
+	if err != nil {
+		return fmt.Errorf("sqlite: failed to shred data key: %w", err)
+	}
+
+	return nil
+}
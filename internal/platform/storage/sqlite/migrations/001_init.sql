@@ -0,0 +1,75 @@
+CREATE TABLE IF NOT EXISTS reports (
+    phone_number TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    id TEXT NOT NULL,
+    country_code TEXT NOT NULL,
+    reporter_hash TEXT NOT NULL,
+    reporter_key_version INTEGER NOT NULL DEFAULT 0,
+    reporter_prev_hash TEXT NOT NULL DEFAULT '',
+    reporter_prev_key_version INTEGER NOT NULL DEFAULT 0,
+    category TEXT NOT NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    lang TEXT NOT NULL DEFAULT '',
+    moderation_status TEXT NOT NULL DEFAULT '',
+    expires_at INTEGER NOT NULL,
+    PRIMARY KEY (phone_number, created_at)
+) WITHOUT ROWID;
+
+CREATE INDEX IF NOT EXISTS reports_expires_at ON reports (expires_at);
+
+CREATE TABLE IF NOT EXISTS scores (
+    phone_number TEXT PRIMARY KEY,
+    country_code TEXT NOT NULL,
+    score REAL NOT NULL,
+    risk_level TEXT NOT NULL,
+    last_activity INTEGER NOT NULL,
+    velocity_hit_count INTEGER NOT NULL,
+    total_reports INTEGER NOT NULL,
+    expires_at INTEGER NOT NULL
+) WITHOUT ROWID;
+
+CREATE INDEX IF NOT EXISTS scores_expires_at ON scores (expires_at);
+
+CREATE TABLE IF NOT EXISTS score_hash_index (
+    hash_prefix TEXT NOT NULL,
+    phone_hash TEXT NOT NULL,
+    score REAL NOT NULL,
+    risk_level TEXT NOT NULL,
+    expires_at INTEGER NOT NULL,
+    PRIMARY KEY (hash_prefix, phone_hash)
+) WITHOUT ROWID;
+
+CREATE INDEX IF NOT EXISTS score_hash_index_expires_at ON score_hash_index (expires_at);
+
+CREATE TABLE IF NOT EXISTS active_threats (
+    country_code TEXT NOT NULL,
+    risk_level TEXT NOT NULL,
+    phone_number TEXT NOT NULL,
+    score REAL NOT NULL,
+    last_updated INTEGER NOT NULL,
+    expires_at INTEGER NOT NULL,
+    PRIMARY KEY (country_code, risk_level, phone_number)
+) WITHOUT ROWID;
+
+CREATE INDEX IF NOT EXISTS active_threats_phone ON active_threats (phone_number);
+CREATE INDEX IF NOT EXISTS active_threats_expires_at ON active_threats (expires_at);
+
+CREATE TABLE IF NOT EXISTS moderation_queue (
+    country_code TEXT NOT NULL,
+    enqueued_at INTEGER NOT NULL,
+    report_id TEXT NOT NULL,
+    phone_number TEXT NOT NULL,
+    report_created_at INTEGER NOT NULL,
+    flags TEXT NOT NULL,
+    expires_at INTEGER NOT NULL,
+    PRIMARY KEY (country_code, enqueued_at, report_id)
+) WITHOUT ROWID;
+
+CREATE INDEX IF NOT EXISTS moderation_queue_expires_at ON moderation_queue (expires_at);
+
+CREATE TABLE IF NOT EXISTS comment_keys (
+    key_id TEXT PRIMARY KEY,
+    wrapped_key BLOB,
+    shredded INTEGER NOT NULL DEFAULT 0,
+    created_at INTEGER NOT NULL
+) WITHOUT ROWID;
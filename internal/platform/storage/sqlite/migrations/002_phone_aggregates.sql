@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS phone_aggregates (
+    phone_number TEXT PRIMARY KEY,
+    country_code TEXT NOT NULL,
+    category_decay TEXT NOT NULL,
+    decayed_at INTEGER NOT NULL,
+    reporter_sketch BLOB,
+    auto_block_days TEXT NOT NULL,
+    last_human_activity INTEGER NOT NULL,
+    total_reports INTEGER NOT NULL,
+    version INTEGER NOT NULL,
+    expires_at INTEGER NOT NULL
+) WITHOUT ROWID;
+
+CREATE INDEX IF NOT EXISTS phone_aggregates_expires_at ON phone_aggregates (expires_at);
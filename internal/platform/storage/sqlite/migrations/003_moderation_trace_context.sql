@@ -0,0 +1 @@
+ALTER TABLE moderation_queue ADD COLUMN trace_context TEXT NOT NULL DEFAULT '{}';
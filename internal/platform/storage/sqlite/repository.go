@@ -0,0 +1,565 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rgdevment/spam-registry/internal/domain"
+	"github.com/rgdevment/spam-registry/internal/service"
+)
+
+// Same lifetime Scylla gives raw reports and queue entries.
+const rawReportTTL = 47304000 * time.Second
+
+const defaultReportPageSize = 500
+
+type sqliteRepository struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+type Option func(*sqliteRepository)
+
+// WithClock replaces time.Now for TTL bookkeeping, so tests can expire rows.
+func WithClock(now func() time.Time) Option {
+	return func(r *sqliteRepository) {
+		r.now = now
+	}
+}
+
+// NewSQLiteRepository emulates Scylla TTLs with an expires_at column: reads
+// ignore expired rows and Sweep deletes them.
+func NewSQLiteRepository(db *sql.DB, opts ...Option) service.Repository {
+	r := &sqliteRepository{
+		db:  db,
+		now: time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *sqliteRepository) SaveRawReport(ctx context.Context, report *domain.Report) error {
+	query := `
+        INSERT OR REPLACE INTO reports (phone_number, created_at, id, country_code, reporter_hash, reporter_key_version,
+                                        reporter_prev_hash, reporter_prev_key_version, category, comment, lang,
+                                        moderation_status, expires_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		report.PhoneNumber,
+		report.CreatedAt.UnixNano(),
+		report.ID.String(),
+		report.CountryCode,
+		report.ReporterHash,
+		report.ReporterKeyVersion,
+		report.ReporterPrevHash,
+		report.ReporterPrevKeyVersion,
+		string(report.Category),
+		report.Comment,
+		report.Lang,
+		string(report.ModerationStatus),
+		r.now().Add(rawReportTTL).UnixNano(),
+	)
+
+	if err != nil {
+		return fmt.Errorf("sqlite: failed to save raw report: %w", err)
+	}
+
+	return nil
+}
+
+func (r *sqliteRepository) GetRawReports(ctx context.Context, phoneNumber string) ([]*domain.Report, error) {
+	var reports []*domain.Report
+	for report, err := range r.StreamRawReports(ctx, phoneNumber, service.ReportRange{}) {
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+// StreamRawReports reads keyset pages: each page starts below the created_at
+// of the previous one, and no rows stay open while the caller handles a
+// report (the pool has a single connection).
+func (r *sqliteRepository) StreamRawReports(ctx context.Context, phoneNumber string, rng service.ReportRange) iter.Seq2[*domain.Report, error] {
+	return func(yield func(*domain.Report, error) bool) {
+		since, until := int64(math.MinInt64), int64(math.MaxInt64)
+		if !rng.Since.IsZero() {
+			since = rng.Since.UnixNano()
+		}
+		if !rng.Until.IsZero() {
+			until = rng.Until.UnixNano()
+		}
+
+		pageSize := rng.PageSize
+		if pageSize <= 0 {
+			pageSize = defaultReportPageSize
+		}
+
+		for {
+			page, err := r.reportPage(ctx, phoneNumber, since, until, pageSize)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			for _, report := range page {
+				if !yield(report, nil) {
+					return
+				}
+			}
+
+			if len(page) < pageSize {
+				return
+			}
+			until = page[len(page)-1].CreatedAt.UnixNano()
+		}
+	}
+}
+
+func (r *sqliteRepository) reportPage(ctx context.Context, phoneNumber string, since, until int64, limit int) ([]*domain.Report, error) {
+	query := `
+        SELECT id, phone_number, country_code, reporter_hash, reporter_key_version, reporter_prev_hash,
+               reporter_prev_key_version, category, comment, created_at, moderation_status, lang
+        FROM reports
+        WHERE phone_number = ? AND expires_at > ? AND created_at >= ? AND created_at < ?
+        ORDER BY created_at DESC
+        LIMIT ?`
+
+	rows, err := r.db.QueryContext(ctx, query, phoneNumber, r.now().UnixNano(), since, until, limit)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: failed to query reports: %w", err)
+	}
+	defer rows.Close()
+
+	var reports []*domain.Report
+	for rows.Next() {
+		var report domain.Report
+		var id, catStr, moderationStr string
+		var createdAt int64
+
+		err := rows.Scan(&id, &report.PhoneNumber, &report.CountryCode, &report.ReporterHash, &report.ReporterKeyVersion,
+			&report.ReporterPrevHash, &report.ReporterPrevKeyVersion, &catStr, &report.Comment, &createdAt,
+			&moderationStr, &report.Lang)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite: failed to scan report: %w", err)
+		}
+
+		report.ID, _ = uuid.Parse(id)
+		report.Category = domain.RiskCategory(catStr)
+		report.CreatedAt = time.Unix(0, createdAt).UTC()
+		report.ModerationStatus = domain.ModerationStatus(moderationStr)
+		reports = append(reports, &report)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite: failed to iterate reports: %w", err)
+	}
+
+	return reports, nil
+}
+
+func (r *sqliteRepository) UpdateReporterHash(ctx context.Context, report *domain.Report) error {
+	query := `
+        UPDATE reports
+        SET reporter_hash = ?, reporter_key_version = ?, reporter_prev_hash = ?, reporter_prev_key_version = ?
+        WHERE phone_number = ? AND created_at = ? AND expires_at > ?`
+
+	_, err := r.db.ExecContext(ctx, query,
+		report.ReporterHash,
+		report.ReporterKeyVersion,
+		report.ReporterPrevHash,
+		report.ReporterPrevKeyVersion,
+		report.PhoneNumber,
+		report.CreatedAt.UnixNano(),
+		r.now().UnixNano(),
+	)
+
+	if err != nil {
+		return fmt.Errorf("sqlite: failed to update reporter hash: %w", err)
+	}
+
+	return nil
+}
+
+func (r *sqliteRepository) GetScore(ctx context.Context, phoneNumber string) (*domain.PhoneScore, error) {
+	query := `
+        SELECT phone_number, country_code, score, risk_level, last_activity, velocity_hit_count, total_reports
+        FROM scores WHERE phone_number = ? AND expires_at > ?`
+
+	var s domain.PhoneScore
+	var riskLevelStr string
+	var lastActivity int64
+
+	err := r.db.QueryRowContext(ctx, query, phoneNumber, r.now().UnixNano()).Scan(
+		&s.PhoneNumber,
+		&s.CountryCode,
+		&s.Score,
+		&riskLevelStr,
+		&lastActivity,
+		&s.VelocityHitCount,
+		&s.TotalReports,
+	)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return &domain.PhoneScore{
+			PhoneNumber: phoneNumber,
+			Score:       0,
+			RiskLevel:   domain.LevelSafe,
+		}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: failed to get score: %w", err)
+	}
+
+	s.RiskLevel = domain.RiskLevel(riskLevelStr)
+	s.LastActivity = time.Unix(0, lastActivity).UTC()
+	return &s, nil
+}
+
+func (r *sqliteRepository) UpsertScore(ctx context.Context, s *domain.PhoneScore, ttlSeconds int) error {
+	expiresAt := r.now().Add(time.Duration(ttlSeconds) * time.Second).UnixNano()
+	phoneHash := domain.HashPhone(s.PhoneNumber)
+
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+            INSERT OR REPLACE INTO scores (phone_number, country_code, score, risk_level, last_activity,
+                                           velocity_hit_count, total_reports, expires_at)
+            VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			s.PhoneNumber,
+			s.CountryCode,
+			s.Score,
+			string(s.RiskLevel),
+			s.LastActivity.UnixNano(),
+			s.VelocityHitCount,
+			s.TotalReports,
+			expiresAt,
+		)
+		if err != nil {
+			return fmt.Errorf("sqlite: failed to upsert score: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+            INSERT OR REPLACE INTO score_hash_index (hash_prefix, phone_hash, score, risk_level, expires_at)
+            VALUES (?, ?, ?, ?, ?)`,
+			phoneHash[:domain.MinHashPrefixLength],
+			phoneHash,
+			s.Score,
+			string(s.RiskLevel),
+			expiresAt,
+		)
+		if err != nil {
+			return fmt.Errorf("sqlite: failed to upsert hash index: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (r *sqliteRepository) UpsertCountryThreat(ctx context.Context, s *domain.PhoneScore, ttlSeconds int) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		// A number lives under a single level; drop the row of its previous level.
+		_, err := tx.ExecContext(ctx, `DELETE FROM active_threats WHERE country_code = ? AND phone_number = ?`,
+			s.CountryCode, s.PhoneNumber)
+		if err != nil {
+			return fmt.Errorf("sqlite: failed to clear previous threat level: %w", err)
+		}
+
+		if s.RiskLevel == domain.LevelSafe {
+			return nil
+		}
+
+		_, err = tx.ExecContext(ctx, `
+            INSERT INTO active_threats (country_code, risk_level, phone_number, score, last_updated, expires_at)
+            VALUES (?, ?, ?, ?, ?, ?)`,
+			s.CountryCode,
+			string(s.RiskLevel),
+			s.PhoneNumber,
+			s.Score,
+			s.LastActivity.UnixNano(),
+			r.now().Add(time.Duration(ttlSeconds)*time.Second).UnixNano(),
+		)
+		if err != nil {
+			return fmt.Errorf("sqlite: failed to upsert country threat: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (r *sqliteRepository) ListCountryThreats(ctx context.Context, countryCode string) ([]*domain.PhoneScore, error) {
+	query := `
+        SELECT risk_level, phone_number, score, last_updated FROM active_threats
+        WHERE country_code = ? AND expires_at > ?
+        ORDER BY risk_level, phone_number`
+
+	rows, err := r.db.QueryContext(ctx, query, countryCode, r.now().UnixNano())
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: failed to query active threats: %w", err)
+	}
+	defer rows.Close()
+
+	var threats []*domain.PhoneScore
+	for rows.Next() {
+		threat := &domain.PhoneScore{CountryCode: countryCode}
+		var levelStr string
+		var lastUpdated int64
+
+		if err := rows.Scan(&levelStr, &threat.PhoneNumber, &threat.Score, &lastUpdated); err != nil {
+			return nil, fmt.Errorf("sqlite: failed to scan active threat: %w", err)
+		}
+
+		threat.RiskLevel = domain.RiskLevel(levelStr)
+		threat.LastActivity = time.Unix(0, lastUpdated).UTC()
+		threats = append(threats, threat)
+	}
+
+	return threats, rows.Err()
+}
+
+func (r *sqliteRepository) DeleteScore(ctx context.Context, phoneNumber string, countryCode string) error {
+	phoneHash := domain.HashPhone(phoneNumber)
+
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM scores WHERE phone_number = ?`, phoneNumber); err != nil {
+			return fmt.Errorf("sqlite: failed to delete score: %w", err)
+		}
+
+		_, err := tx.ExecContext(ctx, `DELETE FROM score_hash_index WHERE hash_prefix = ? AND phone_hash = ?`,
+			phoneHash[:domain.MinHashPrefixLength], phoneHash)
+		if err != nil {
+			return fmt.Errorf("sqlite: failed to delete hash index: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM active_threats WHERE country_code = ? AND phone_number = ?`,
+			countryCode, phoneNumber)
+		if err != nil {
+			return fmt.Errorf("sqlite: failed to delete country threat: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (r *sqliteRepository) GetScoresByHashPrefix(ctx context.Context, prefix string) ([]*domain.HashedScore, error) {
+	query := `
+        SELECT phone_hash, score, risk_level FROM score_hash_index
+        WHERE hash_prefix = ? AND phone_hash >= ? AND phone_hash < ? AND expires_at > ?
+        ORDER BY phone_hash`
+
+	rows, err := r.db.QueryContext(ctx, query,
+		prefix[:domain.MinHashPrefixLength], prefix, prefix+"g", r.now().UnixNano())
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: failed to read hash prefix index: %w", err)
+	}
+	defer rows.Close()
+
+	matches := []*domain.HashedScore{}
+	for rows.Next() {
+		var m domain.HashedScore
+		var levelStr string
+		if err := rows.Scan(&m.PhoneHash, &m.Score, &levelStr); err != nil {
+			return nil, fmt.Errorf("sqlite: failed to scan hash index: %w", err)
+		}
+		m.RiskLevel = domain.RiskLevel(levelStr)
+		matches = append(matches, &m)
+	}
+
+	return matches, rows.Err()
+}
+
+func (r *sqliteRepository) EnqueueModeration(ctx context.Context, item *domain.ModerationItem) error {
+	flags, err := json.Marshal(item.Flags)
+	if err != nil {
+		return err
+	}
+	traceContext, err := json.Marshal(item.TraceContext)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+        INSERT OR REPLACE INTO moderation_queue (country_code, enqueued_at, report_id, phone_number,
+                                                 report_created_at, flags, trace_context, expires_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		item.CountryCode,
+		item.EnqueuedAt.UnixNano(),
+		item.ReportID.String(),
+		item.PhoneNumber,
+		item.ReportCreatedAt.UnixNano(),
+		string(flags),
+		string(traceContext),
+		r.now().Add(rawReportTTL).UnixNano(),
+	)
+
+	if err != nil {
+		return fmt.Errorf("sqlite: failed to enqueue moderation item: %w", err)
+	}
+
+	return nil
+}
+
+func (r *sqliteRepository) ListModerationQueue(ctx context.Context, countryCode string, limit int) ([]*domain.ModerationItem, error) {
+	query := `
+        SELECT report_id, phone_number, report_created_at, flags, enqueued_at, trace_context
+        FROM moderation_queue WHERE country_code = ? AND expires_at > ?
+        ORDER BY enqueued_at, report_id LIMIT ?`
+
+	rows, err := r.db.QueryContext(ctx, query, countryCode, r.now().UnixNano(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: failed to query moderation queue: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*domain.ModerationItem
+	for rows.Next() {
+		var id, flags, traceContext string
+		var reportCreatedAt, enqueuedAt int64
+		item := &domain.ModerationItem{CountryCode: countryCode}
+
+		if err := rows.Scan(&id, &item.PhoneNumber, &reportCreatedAt, &flags, &enqueuedAt, &traceContext); err != nil {
+			return nil, fmt.Errorf("sqlite: failed to scan moderation item: %w", err)
+		}
+
+		item.ReportID, _ = uuid.Parse(id)
+		item.ReportCreatedAt = time.Unix(0, reportCreatedAt).UTC()
+		item.EnqueuedAt = time.Unix(0, enqueuedAt).UTC()
+		if err := json.Unmarshal([]byte(flags), &item.Flags); err != nil {
+			return nil, fmt.Errorf("sqlite: corrupt moderation flags: %w", err)
+		}
+		if err := json.Unmarshal([]byte(traceContext), &item.TraceContext); err != nil {
+			return nil, fmt.Errorf("sqlite: corrupt moderation trace context: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (r *sqliteRepository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("sqlite: failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *sqliteRepository) GetAggregate(ctx context.Context, phoneNumber string) (*domain.PhoneAggregate, error) {
+	query := `
+        SELECT country_code, category_decay, decayed_at, reporter_sketch, auto_block_days,
+               last_human_activity, total_reports, version
+        FROM phone_aggregates WHERE phone_number = ? AND expires_at > ?`
+
+	agg := domain.NewPhoneAggregate(phoneNumber)
+	var categoryDecay, autoBlockDays string
+	var decayedAt, lastHumanActivity int64
+
+	err := r.db.QueryRowContext(ctx, query, phoneNumber, r.now().UnixNano()).Scan(
+		&agg.CountryCode,
+		&categoryDecay,
+		&decayedAt,
+		&agg.ReporterSketch,
+		&autoBlockDays,
+		&lastHumanActivity,
+		&agg.TotalReports,
+		&agg.Version,
+	)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return agg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: failed to get aggregate: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(categoryDecay), &agg.CategoryDecay); err != nil {
+		return nil, fmt.Errorf("sqlite: corrupt aggregate: %w", err)
+	}
+	if err := json.Unmarshal([]byte(autoBlockDays), &agg.AutoBlockDays); err != nil {
+		return nil, fmt.Errorf("sqlite: corrupt aggregate: %w", err)
+	}
+	agg.DecayedAt = time.Unix(0, decayedAt).UTC()
+	agg.LastHumanActivity = time.Unix(0, lastHumanActivity).UTC()
+
+	return agg, nil
+}
+
+// CompareAndSwapAggregate treats an expired row like a missing one, as
+// Scylla does, so version 0 may overwrite it.
+func (r *sqliteRepository) CompareAndSwapAggregate(ctx context.Context, agg *domain.PhoneAggregate, ttlSeconds int) (bool, error) {
+	categoryDecay, err := json.Marshal(agg.CategoryDecay)
+	if err != nil {
+		return false, err
+	}
+	autoBlockDays, err := json.Marshal(agg.AutoBlockDays)
+	if err != nil {
+		return false, err
+	}
+
+	now := r.now()
+	expiresAt := now.Add(time.Duration(ttlSeconds) * time.Second).UnixNano()
+
+	values := []any{
+		agg.CountryCode, string(categoryDecay), agg.DecayedAt.UnixNano(), agg.ReporterSketch, string(autoBlockDays),
+		agg.LastHumanActivity.UnixNano(), agg.TotalReports, agg.Version + 1, expiresAt, agg.PhoneNumber,
+	}
+
+	var res sql.Result
+	if agg.Version == 0 {
+		res, err = r.db.ExecContext(ctx, `
+        INSERT INTO phone_aggregates (country_code, category_decay, decayed_at, reporter_sketch, auto_block_days,
+                                      last_human_activity, total_reports, version, expires_at, phone_number)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        ON CONFLICT (phone_number) DO UPDATE SET
+            country_code = excluded.country_code,
+            category_decay = excluded.category_decay,
+            decayed_at = excluded.decayed_at,
+            reporter_sketch = excluded.reporter_sketch,
+            auto_block_days = excluded.auto_block_days,
+            last_human_activity = excluded.last_human_activity,
+            total_reports = excluded.total_reports,
+            version = excluded.version,
+            expires_at = excluded.expires_at
+        WHERE phone_aggregates.expires_at <= ?`,
+			append(values, now.UnixNano())...)
+	} else {
+		res, err = r.db.ExecContext(ctx, `
+        UPDATE phone_aggregates
+        SET country_code = ?, category_decay = ?, decayed_at = ?, reporter_sketch = ?, auto_block_days = ?,
+            last_human_activity = ?, total_reports = ?, version = ?, expires_at = ?
+        WHERE phone_number = ? AND version = ? AND expires_at > ?`,
+			append(values, agg.Version, now.UnixNano())...)
+	}
+	if err != nil {
+		return false, fmt.Errorf("sqlite: failed to store aggregate: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if n == 0 {
+		return false, nil
+	}
+
+	agg.Version++
+	return true, nil
+}
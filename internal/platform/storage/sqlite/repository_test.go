@@ -0,0 +1,50 @@
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/sqlite"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/storagetest"
+	"github.com/rgdevment/spam-registry/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositoryContract(t *testing.T) {
+	storagetest.RunRepositoryContract(t, func(t *testing.T, now func() time.Time) service.Repository {
+		db, err := sqlite.Open(context.Background(), filepath.Join(t.TempDir(), "gsr.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		return sqlite.NewSQLiteRepository(db, sqlite.WithClock(now))
+	})
+}
+
+func TestMigrateIsIdempotentAndSweepExpires(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "gsr.db")
+
+	db, err := sqlite.Open(ctx, path)
+	require.NoError(t, err)
+	require.NoError(t, sqlite.Migrate(ctx, db))
+	defer db.Close()
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	repo := sqlite.NewSQLiteRepository(db, sqlite.WithClock(func() time.Time { return now }))
+
+	require.NoError(t, repo.UpsertScore(ctx, &domain.PhoneScore{
+		PhoneNumber: "+56987654321", CountryCode: "CL", Score: 42, RiskLevel: domain.LevelWarning,
+	}, 60))
+
+	score, err := repo.GetScore(ctx, "+56987654321")
+	require.NoError(t, err)
+	assert.Equal(t, 42.0, score.Score)
+
+	removed, err := sqlite.Sweep(ctx, db, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), removed, "score and its hash index entry")
+}
@@ -0,0 +1,46 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/rgdevment/spam-registry/internal/platform/logging"
+)
+
+var expiringTables = []string{"reports", "scores", "score_hash_index", "active_threats", "moderation_queue", "phone_aggregates"}
+
+// Sweep deletes every row whose TTL has passed and returns how many went.
+func Sweep(ctx context.Context, db *sql.DB, now time.Time) (int64, error) {
+	var total int64
+
+	for _, table := range expiringTables {
+		res, err := db.ExecContext(ctx, "DELETE FROM "+table+" WHERE expires_at <= ?", now.UnixNano())
+		if err != nil {
+			return total, fmt.Errorf("sqlite: failed to sweep %s: %w", table, err)
+		}
+		n, _ := res.RowsAffected()
+		total += n
+	}
+
+	return total, nil
+}
+
+// RunSweeper calls Sweep every interval until ctx is done.
+func RunSweeper(ctx context.Context, db *sql.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := Sweep(ctx, db, time.Now()); err != nil {
+				slog.Warn("⚠️  sqlite: sweep failed", logging.Err(err))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,577 @@
+// Package storagetest holds the behaviour every service.Repository backend
+// must share. Each backend runs RunRepositoryContract from its own tests.
+package storagetest
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rgdevment/spam-registry/internal/domain"
+	"github.com/rgdevment/spam-registry/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory returns an empty repository that reads time from now. Backends
+// whose TTLs are enforced by the server may ignore now; run them with
+// RealTime.
+type Factory func(t *testing.T, now func() time.Time) service.Repository
+
+type Option func(*contract)
+
+// RealTime makes the contract wait out TTLs on the wall clock instead of
+// moving a fake clock. Checks that would take months are skipped.
+func RealTime() Option {
+	return func(c *contract) {
+		c.realTime = true
+	}
+}
+
+type contract struct {
+	factory  Factory
+	realTime bool
+}
+
+type clock struct {
+	mu       sync.Mutex
+	now      time.Time
+	realTime bool
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	if c.realTime {
+		time.Sleep(d)
+	}
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func RunRepositoryContract(t *testing.T, factory Factory, opts ...Option) {
+	c := &contract{factory: factory}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	t.Run("RawReportsNewestFirst", c.testRawReportsNewestFirst)
+	t.Run("RawReportSameInstantReplaces", c.testRawReportSameInstantReplaces)
+	t.Run("StreamRawReportsRange", c.testStreamRawReportsRange)
+	t.Run("UpdateReporterHash", c.testUpdateReporterHash)
+	t.Run("ScoreNotFoundIsSafe", c.testScoreNotFoundIsSafe)
+	t.Run("ScoreRoundTrip", c.testScoreRoundTrip)
+	t.Run("DeleteScore", c.testDeleteScore)
+	t.Run("HashPrefixIndex", c.testHashPrefixIndex)
+	t.Run("ScoreTTL", c.testScoreTTL)
+	t.Run("RawReportTTL", c.testRawReportTTL)
+	t.Run("ThreatIndexConsistency", c.testThreatIndexConsistency)
+	t.Run("ModerationQueue", c.testModerationQueue)
+	t.Run("AggregateCompareAndSwap", c.testAggregateCompareAndSwap)
+	t.Run("Concurrency", c.testConcurrency)
+}
+
+func (c *contract) setup(t *testing.T) (service.Repository, *clock) {
+	// Millisecond precision is the lowest common denominator (Scylla timestamps).
+	clk := &clock{now: time.Now().UTC().Truncate(time.Millisecond), realTime: c.realTime}
+	return c.factory(t, clk.Now), clk
+}
+
+var phoneSeq atomic.Int64
+
+func init() {
+	phoneSeq.Store(rand.Int64N(5000000))
+}
+
+// uniquePhone keeps subtests independent on backends that share a keyspace.
+func uniquePhone() string {
+	return fmt.Sprintf("+5698%07d", phoneSeq.Add(1)%10000000)
+}
+
+func newReport(phone, reporter string, cat domain.RiskCategory, at time.Time) *domain.Report {
+	r := domain.NewReport(phone, "CL", reporter, cat, "comentario de prueba")
+	r.CreatedAt = at
+	r.ReporterKeyVersion = 1
+	r.ModerationStatus = domain.ModerationPublished
+	r.Lang = "es"
+	return r
+}
+
+func (c *contract) testRawReportsNewestFirst(t *testing.T) {
+	repo, clk := c.setup(t)
+	ctx := context.Background()
+	phone, other := uniquePhone(), uniquePhone()
+
+	base := clk.Now()
+	for i, offset := range []time.Duration{-48 * time.Hour, -time.Hour, -72 * time.Hour} {
+		require.NoError(t, repo.SaveRawReport(ctx, newReport(phone, fmt.Sprintf("r%d", i), domain.RiskSpam, base.Add(offset))))
+	}
+	require.NoError(t, repo.SaveRawReport(ctx, newReport(other, "r9", domain.RiskFraud, base)))
+
+	reports, err := repo.GetRawReports(ctx, phone)
+	require.NoError(t, err)
+	require.Len(t, reports, 3)
+
+	assert.Equal(t, "r1", reports[0].ReporterHash)
+	assert.Equal(t, "r0", reports[1].ReporterHash)
+	assert.Equal(t, "r2", reports[2].ReporterHash)
+	for _, r := range reports {
+		assert.Equal(t, phone, r.PhoneNumber)
+	}
+
+	first := reports[0]
+	assert.Equal(t, "CL", first.CountryCode)
+	assert.Equal(t, domain.RiskSpam, first.Category)
+	assert.Equal(t, "comentario de prueba", first.Comment)
+	assert.Equal(t, "es", first.Lang)
+	assert.Equal(t, domain.ModerationPublished, first.ModerationStatus)
+	assert.Equal(t, 1, first.ReporterKeyVersion)
+	assert.True(t, base.Add(-time.Hour).Equal(first.CreatedAt))
+
+	none, err := repo.GetRawReports(ctx, uniquePhone())
+	require.NoError(t, err)
+	assert.Empty(t, none)
+}
+
+func (c *contract) testStreamRawReportsRange(t *testing.T) {
+	repo, clk := c.setup(t)
+	ctx := context.Background()
+	phone := uniquePhone()
+
+	base := clk.Now()
+	for h := 0; h < 6; h++ {
+		at := base.Add(-time.Duration(h) * time.Hour)
+		require.NoError(t, repo.SaveRawReport(ctx, newReport(phone, fmt.Sprintf("h%d", h), domain.RiskSpam, at)))
+	}
+
+	collect := func(rng service.ReportRange) []string {
+		var hashes []string
+		for r, err := range repo.StreamRawReports(ctx, phone, rng) {
+			require.NoError(t, err)
+			hashes = append(hashes, r.ReporterHash)
+		}
+		return hashes
+	}
+
+	assert.Equal(t, []string{"h0", "h1", "h2", "h3", "h4", "h5"}, collect(service.ReportRange{PageSize: 2}),
+		"pages are stitched newest first")
+	assert.Equal(t, []string{"h1", "h2", "h3"}, collect(service.ReportRange{
+		Since:    base.Add(-3 * time.Hour),
+		Until:    base,
+		PageSize: 2,
+	}), "since is inclusive, until exclusive")
+	assert.Equal(t, []string{"h4", "h5"}, collect(service.ReportRange{Until: base.Add(-3*time.Hour - time.Millisecond)}))
+
+	var read int
+	for _, err := range repo.StreamRawReports(ctx, phone, service.ReportRange{PageSize: 2}) {
+		require.NoError(t, err)
+		read++
+		if read == 3 {
+			break
+		}
+	}
+	assert.Equal(t, 3, read, "breaking out of the loop stops the stream")
+}
+
+func (c *contract) testRawReportSameInstantReplaces(t *testing.T) {
+	repo, clk := c.setup(t)
+	ctx := context.Background()
+	phone := uniquePhone()
+
+	at := clk.Now()
+	require.NoError(t, repo.SaveRawReport(ctx, newReport(phone, "first", domain.RiskSpam, at)))
+	require.NoError(t, repo.SaveRawReport(ctx, newReport(phone, "second", domain.RiskFraud, at)))
+
+	reports, err := repo.GetRawReports(ctx, phone)
+	require.NoError(t, err)
+	require.Len(t, reports, 1, "(phone_number, created_at) is the primary key")
+	assert.Equal(t, "second", reports[0].ReporterHash)
+}
+
+func (c *contract) testUpdateReporterHash(t *testing.T) {
+	repo, clk := c.setup(t)
+	ctx := context.Background()
+	phone := uniquePhone()
+
+	r := newReport(phone, "old-hash", domain.RiskFraud, clk.Now())
+	r.ReporterPrevHash = "older-hash"
+	r.ReporterPrevKeyVersion = 1
+	require.NoError(t, repo.SaveRawReport(ctx, r))
+
+	updated := *r
+	updated.ReporterHash = "new-hash"
+	updated.ReporterKeyVersion = 2
+	updated.ReporterPrevHash = ""
+	updated.ReporterPrevKeyVersion = 0
+	updated.Comment = "must not be written"
+	require.NoError(t, repo.UpdateReporterHash(ctx, &updated))
+
+	reports, err := repo.GetRawReports(ctx, phone)
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, "new-hash", reports[0].ReporterHash)
+	assert.Equal(t, 2, reports[0].ReporterKeyVersion)
+	assert.Empty(t, reports[0].ReporterPrevHash)
+	assert.Equal(t, "comentario de prueba", reports[0].Comment, "only reporter fields change")
+}
+
+func (c *contract) testScoreNotFoundIsSafe(t *testing.T) {
+	repo, _ := c.setup(t)
+	phone := uniquePhone()
+
+	score, err := repo.GetScore(context.Background(), phone)
+	require.NoError(t, err)
+	require.NotNil(t, score, "unknown numbers are SAFE, not nil")
+	assert.Equal(t, phone, score.PhoneNumber)
+	assert.Equal(t, domain.LevelSafe, score.RiskLevel)
+	assert.Zero(t, score.Score)
+}
+
+func sampleScore(phone string, score float64, level domain.RiskLevel, at time.Time) *domain.PhoneScore {
+	return &domain.PhoneScore{
+		PhoneNumber:      phone,
+		CountryCode:      "CL",
+		Score:            score,
+		RiskLevel:        level,
+		LastActivity:     at,
+		VelocityHitCount: 3,
+		TotalReports:     7,
+	}
+}
+
+func (c *contract) testScoreRoundTrip(t *testing.T) {
+	repo, clk := c.setup(t)
+	ctx := context.Background()
+	phone := uniquePhone()
+
+	require.NoError(t, repo.UpsertScore(ctx, sampleScore(phone, 30, domain.LevelWarning, clk.Now()), 3600))
+	require.NoError(t, repo.UpsertScore(ctx, sampleScore(phone, 75.5, domain.LevelCritical, clk.Now()), 3600))
+
+	score, err := repo.GetScore(ctx, phone)
+	require.NoError(t, err)
+	assert.Equal(t, phone, score.PhoneNumber)
+	assert.Equal(t, "CL", score.CountryCode)
+	assert.Equal(t, 75.5, score.Score)
+	assert.Equal(t, domain.LevelCritical, score.RiskLevel)
+	assert.True(t, clk.Now().Equal(score.LastActivity))
+	assert.Equal(t, 3, score.VelocityHitCount)
+	assert.Equal(t, 7, score.TotalReports)
+}
+
+func (c *contract) testDeleteScore(t *testing.T) {
+	repo, clk := c.setup(t)
+	ctx := context.Background()
+	phone := uniquePhone()
+	hash := domain.HashPhone(phone)
+
+	require.NoError(t, repo.UpsertScore(ctx, sampleScore(phone, 80, domain.LevelCritical, clk.Now()), 3600))
+	require.NoError(t, repo.DeleteScore(ctx, phone, "CL"))
+
+	score, err := repo.GetScore(ctx, phone)
+	require.NoError(t, err)
+	assert.Equal(t, domain.LevelSafe, score.RiskLevel)
+
+	matches, err := repo.GetScoresByHashPrefix(ctx, hash[:domain.MaxHashPrefixLength])
+	require.NoError(t, err)
+	assert.Empty(t, matches, "deleting a score must drop its hash index entry")
+
+	require.NoError(t, repo.DeleteScore(ctx, uniquePhone(), "CL"), "deleting an unknown number is a no-op")
+}
+
+func (c *contract) testHashPrefixIndex(t *testing.T) {
+	repo, clk := c.setup(t)
+	ctx := context.Background()
+	phone := uniquePhone()
+	hash := domain.HashPhone(phone)
+
+	require.NoError(t, repo.UpsertScore(ctx, sampleScore(phone, 64, domain.LevelCritical, clk.Now()), 3600))
+
+	for _, n := range []int{domain.MinHashPrefixLength, domain.MaxHashPrefixLength} {
+		matches, err := repo.GetScoresByHashPrefix(ctx, hash[:n])
+		require.NoError(t, err)
+
+		var found *domain.HashedScore
+		for i, m := range matches {
+			if i > 0 {
+				assert.Less(t, matches[i-1].PhoneHash, m.PhoneHash, "matches are sorted by hash")
+			}
+			assert.True(t, strings.HasPrefix(m.PhoneHash, hash[:n]))
+			if m.PhoneHash == hash {
+				found = m
+			}
+		}
+		require.NotNil(t, found, "prefix length %d", n)
+		assert.Equal(t, 64.0, found.Score)
+		assert.Equal(t, domain.LevelCritical, found.RiskLevel)
+	}
+
+	// Flip the last prefix character to get a prefix that cannot match.
+	miss := hash[:domain.MaxHashPrefixLength-1] + string("1032547698badcfe"[hexValue(hash[domain.MaxHashPrefixLength-1])])
+	matches, err := repo.GetScoresByHashPrefix(ctx, miss)
+	require.NoError(t, err)
+	for _, m := range matches {
+		assert.NotEqual(t, hash, m.PhoneHash)
+	}
+}
+
+func hexValue(c byte) int {
+	if c >= 'a' {
+		return int(c-'a') + 10
+	}
+	return int(c - '0')
+}
+
+func (c *contract) testScoreTTL(t *testing.T) {
+	repo, clk := c.setup(t)
+	ctx := context.Background()
+	phone := uniquePhone()
+	hash := domain.HashPhone(phone)
+
+	require.NoError(t, repo.UpsertScore(ctx, sampleScore(phone, 50, domain.LevelWarning, clk.Now()), 1))
+	require.NoError(t, repo.UpsertCountryThreat(ctx, sampleScore(phone, 50, domain.LevelWarning, clk.Now()), 1))
+
+	clk.Advance(1500 * time.Millisecond)
+
+	score, err := repo.GetScore(ctx, phone)
+	require.NoError(t, err)
+	assert.Equal(t, domain.LevelSafe, score.RiskLevel, "expired scores read as not found")
+
+	matches, err := repo.GetScoresByHashPrefix(ctx, hash[:domain.MaxHashPrefixLength])
+	require.NoError(t, err)
+	assert.Empty(t, matches, "hash index entries share the score TTL")
+
+	threats, err := repo.ListCountryThreats(ctx, "CL")
+	require.NoError(t, err)
+	for _, threat := range threats {
+		assert.NotEqual(t, phone, threat.PhoneNumber, "threat entries expire with their TTL")
+	}
+}
+
+func (c *contract) testRawReportTTL(t *testing.T) {
+	if c.realTime {
+		t.Skip("raw reports live for 18 months")
+	}
+
+	repo, clk := c.setup(t)
+	ctx := context.Background()
+	phone := uniquePhone()
+
+	require.NoError(t, repo.SaveRawReport(ctx, newReport(phone, "r", domain.RiskSpam, clk.Now())))
+
+	clk.Advance(547 * 24 * time.Hour)
+	reports, err := repo.GetRawReports(ctx, phone)
+	require.NoError(t, err)
+	assert.Len(t, reports, 1, "reports live 47304000 seconds")
+
+	clk.Advance(24 * time.Hour)
+	reports, err = repo.GetRawReports(ctx, phone)
+	require.NoError(t, err)
+	assert.Empty(t, reports)
+}
+
+func threatLevelOf(t *testing.T, repo service.Repository, phone string) (domain.RiskLevel, int) {
+	threats, err := repo.ListCountryThreats(context.Background(), "CL")
+	require.NoError(t, err)
+
+	var level domain.RiskLevel
+	count := 0
+	for _, threat := range threats {
+		if threat.PhoneNumber == phone {
+			level = threat.RiskLevel
+			count++
+		}
+	}
+	return level, count
+}
+
+func (c *contract) testThreatIndexConsistency(t *testing.T) {
+	repo, clk := c.setup(t)
+	ctx := context.Background()
+	phone := uniquePhone()
+
+	require.NoError(t, repo.UpsertCountryThreat(ctx, sampleScore(phone, 30, domain.LevelWarning, clk.Now()), 3600))
+	level, count := threatLevelOf(t, repo, phone)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, domain.LevelWarning, level)
+
+	require.NoError(t, repo.UpsertCountryThreat(ctx, sampleScore(phone, 90, domain.LevelCritical, clk.Now()), 3600))
+	level, count = threatLevelOf(t, repo, phone)
+	assert.Equal(t, 1, count, "a number is listed under a single level")
+	assert.Equal(t, domain.LevelCritical, level)
+
+	require.NoError(t, repo.UpsertCountryThreat(ctx, sampleScore(phone, 10, domain.LevelSafe, clk.Now()), 3600))
+	_, count = threatLevelOf(t, repo, phone)
+	assert.Zero(t, count, "SAFE numbers leave the threat index")
+
+	require.NoError(t, repo.UpsertCountryThreat(ctx, sampleScore(phone, 90, domain.LevelCritical, clk.Now()), 3600))
+	require.NoError(t, repo.DeleteScore(ctx, phone, "CL"))
+	_, count = threatLevelOf(t, repo, phone)
+	assert.Zero(t, count, "DeleteScore removes the threat entry")
+
+	threats, err := repo.ListCountryThreats(ctx, "CL")
+	require.NoError(t, err)
+	for i := 1; i < len(threats); i++ {
+		prev, cur := threats[i-1], threats[i]
+		ordered := prev.RiskLevel < cur.RiskLevel || (prev.RiskLevel == cur.RiskLevel && prev.PhoneNumber < cur.PhoneNumber)
+		assert.True(t, ordered, "threats are ordered by level, then number")
+	}
+}
+
+func (c *contract) testModerationQueue(t *testing.T) {
+	repo, clk := c.setup(t)
+	ctx := context.Background()
+	country := "T-" + uuid.NewString()[:8]
+
+	var ids []uuid.UUID
+	for i := 0; i < 3; i++ {
+		item := &domain.ModerationItem{
+			ReportID:        uuid.New(),
+			PhoneNumber:     uniquePhone(),
+			CountryCode:     country,
+			ReportCreatedAt: clk.Now(),
+			Flags:           []domain.ModerationFlag{domain.FlagThreat, domain.FlagProfanity},
+			EnqueuedAt:      clk.Now().Add(time.Duration(i) * time.Second),
+			TraceContext:    map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		}
+		ids = append(ids, item.ReportID)
+		require.NoError(t, repo.EnqueueModeration(ctx, item))
+	}
+
+	again := &domain.ModerationItem{
+		ReportID:        ids[2],
+		PhoneNumber:     uniquePhone(),
+		CountryCode:     country,
+		ReportCreatedAt: clk.Now(),
+		Flags:           []domain.ModerationFlag{domain.FlagThreat},
+		EnqueuedAt:      clk.Now().Add(2 * time.Second),
+	}
+	require.NoError(t, repo.EnqueueModeration(ctx, again), "enqueueing the same item twice replaces it")
+	all, err := repo.ListModerationQueue(ctx, country, 10)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, []domain.ModerationFlag{domain.FlagThreat}, all[2].Flags)
+
+	items, err := repo.ListModerationQueue(ctx, country, 2)
+	require.NoError(t, err)
+	require.Len(t, items, 2, "limit is honoured")
+	assert.Equal(t, ids[0], items[0].ReportID, "oldest first")
+	assert.Equal(t, ids[1], items[1].ReportID)
+	assert.Equal(t, country, items[0].CountryCode)
+	assert.Equal(t, []domain.ModerationFlag{domain.FlagThreat, domain.FlagProfanity}, items[0].Flags)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", items[0].TraceContext["traceparent"])
+
+	others, err := repo.ListModerationQueue(ctx, "ZZ", 10)
+	require.NoError(t, err)
+	assert.Empty(t, others)
+}
+
+func (c *contract) testAggregateCompareAndSwap(t *testing.T) {
+	repo, clk := c.setup(t)
+	ctx := context.Background()
+	phone := uniquePhone()
+
+	missing, err := repo.GetAggregate(ctx, phone)
+	require.NoError(t, err)
+	assert.Equal(t, phone, missing.PhoneNumber)
+	assert.Zero(t, missing.Version, "absent aggregates have version 0")
+
+	agg := domain.NewPhoneAggregate(phone)
+	agg.CountryCode = "CL"
+	agg.CategoryDecay[domain.RiskFraud] = 1.5
+	agg.DecayedAt = clk.Now()
+	agg.ReporterSketch = []byte{1, 2, 3}
+	agg.AutoBlockDays[domain.DayBucket(clk.Now())] = 4
+	agg.LastHumanActivity = clk.Now()
+	agg.TotalReports = 7
+
+	swapped, err := repo.CompareAndSwapAggregate(ctx, agg, 1)
+	require.NoError(t, err)
+	require.True(t, swapped)
+	assert.Equal(t, int64(1), agg.Version)
+
+	stored, err := repo.GetAggregate(ctx, phone)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stored.Version)
+	assert.Equal(t, "CL", stored.CountryCode)
+	assert.Equal(t, 1.5, stored.CategoryDecay[domain.RiskFraud])
+	assert.True(t, clk.Now().Equal(stored.DecayedAt))
+	assert.Equal(t, []byte{1, 2, 3}, stored.ReporterSketch)
+	assert.Equal(t, 4, stored.AutoBlockDays[domain.DayBucket(clk.Now())])
+	assert.Equal(t, 7, stored.TotalReports)
+
+	stale := domain.NewPhoneAggregate(phone)
+	swapped, err = repo.CompareAndSwapAggregate(ctx, stale, 1)
+	require.NoError(t, err)
+	assert.False(t, swapped, "version 0 must not overwrite a stored aggregate")
+	assert.Zero(t, stale.Version)
+
+	ahead := *stored
+	ahead.Version = 5
+	swapped, err = repo.CompareAndSwapAggregate(ctx, &ahead, 1)
+	require.NoError(t, err)
+	assert.False(t, swapped, "a version that was never stored must not match")
+
+	stored.TotalReports = 8
+	swapped, err = repo.CompareAndSwapAggregate(ctx, stored, 1)
+	require.NoError(t, err)
+	require.True(t, swapped)
+
+	current, err := repo.GetAggregate(ctx, phone)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), current.Version)
+	assert.Equal(t, 8, current.TotalReports)
+
+	clk.Advance(1500 * time.Millisecond)
+
+	expired, err := repo.GetAggregate(ctx, phone)
+	require.NoError(t, err)
+	assert.Zero(t, expired.Version, "aggregates expire with their TTL")
+
+	swapped, err = repo.CompareAndSwapAggregate(ctx, expired, 60)
+	require.NoError(t, err)
+	assert.True(t, swapped, "an expired aggregate can be recreated")
+}
+
+func (c *contract) testConcurrency(t *testing.T) {
+	repo, clk := c.setup(t)
+	ctx := context.Background()
+	phone := uniquePhone()
+	base := clk.Now()
+
+	const writers = 16
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*3)
+
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			at := base.Add(-time.Duration(i) * time.Minute)
+			errs <- repo.SaveRawReport(ctx, newReport(phone, fmt.Sprintf("r%d", i), domain.RiskSpam, at))
+			errs <- repo.UpsertScore(ctx, sampleScore(phone, float64(i), domain.LevelSafe, at), 3600)
+			_, err := repo.GetScore(ctx, phone)
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	reports, err := repo.GetRawReports(ctx, phone)
+	require.NoError(t, err)
+	assert.Len(t, reports, writers, "no concurrent write is lost")
+}
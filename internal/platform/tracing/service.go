@@ -0,0 +1,107 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+	"github.com/rgdevment/spam-registry/internal/service"
+)
+
+const instrumentation = "github.com/rgdevment/spam-registry/internal/service"
+
+// tracedService opens a child span around every Service call. Phone numbers
+// and reporter IDs are never recorded as attributes.
+type tracedService struct {
+	inner service.Service
+}
+
+func NewService(inner service.Service) service.Service {
+	return &tracedService{inner: inner}
+}
+
+func start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentation).Start(ctx, "service."+name, trace.WithAttributes(attrs...))
+}
+
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (s *tracedService) IngestReport(ctx context.Context, rawPhone, rawReporter, category, comment, lang string) error {
+	ctx, span := start(ctx, "IngestReport", attribute.String("gsr.report.category", category))
+	err := s.inner.IngestReport(ctx, rawPhone, rawReporter, category, comment, lang)
+	end(span, err)
+	return err
+}
+
+func (s *tracedService) CheckRisk(ctx context.Context, phoneNumber string) (*domain.PhoneScore, error) {
+	ctx, span := start(ctx, "CheckRisk")
+	score, err := s.inner.CheckRisk(ctx, phoneNumber)
+	if score != nil {
+		span.SetAttributes(
+			attribute.String("gsr.score.level", string(score.RiskLevel)),
+			attribute.Bool("gsr.score.stale", score.Stale),
+		)
+	}
+	end(span, err)
+	return score, err
+}
+
+func (s *tracedService) CheckRiskByHashPrefix(ctx context.Context, prefix string) ([]*domain.HashedScore, error) {
+	ctx, span := start(ctx, "CheckRiskByHashPrefix", attribute.Int("gsr.hash_prefix.length", len(prefix)))
+	matches, err := s.inner.CheckRiskByHashPrefix(ctx, prefix)
+	span.SetAttributes(attribute.Int("gsr.hash_prefix.matches", len(matches)))
+	end(span, err)
+	return matches, err
+}
+
+func (s *tracedService) ListPublicReports(ctx context.Context, phoneNumber, lang string, page, limit int) (*domain.PublicReportPage, error) {
+	ctx, span := start(ctx, "ListPublicReports", attribute.Int("gsr.page", page), attribute.Int("gsr.limit", limit))
+	result, err := s.inner.ListPublicReports(ctx, phoneNumber, lang, page, limit)
+	end(span, err)
+	return result, err
+}
+
+func (s *tracedService) CalculateAndSaveRisk(ctx context.Context, phoneNumber string) error {
+	ctx, span := start(ctx, "CalculateAndSaveRisk")
+	err := s.inner.CalculateAndSaveRisk(ctx, phoneNumber)
+	end(span, err)
+	return err
+}
+
+func (s *tracedService) ExplainRisk(ctx context.Context, phoneNumber string) (*domain.ScoreExplanation, error) {
+	ctx, span := start(ctx, "ExplainRisk")
+	x, err := s.inner.ExplainRisk(ctx, phoneNumber)
+	end(span, err)
+	return x, err
+}
+
+func (s *tracedService) RehashReporters(ctx context.Context, phoneNumber string) (int, error) {
+	ctx, span := start(ctx, "RehashReporters")
+	updated, err := s.inner.RehashReporters(ctx, phoneNumber)
+	span.SetAttributes(attribute.Int("gsr.reports.rehashed", updated))
+	end(span, err)
+	return updated, err
+}
+
+func (s *tracedService) RebuildAggregate(ctx context.Context, phoneNumber string) error {
+	ctx, span := start(ctx, "RebuildAggregate")
+	err := s.inner.RebuildAggregate(ctx, phoneNumber)
+	end(span, err)
+	return err
+}
+
+// ReplaySpooled is not wrapped here: the service continues the trace of the
+// original ingest, which only it can read from the payload.
+func (s *tracedService) ReplaySpooled(ctx context.Context, payload []byte) error {
+	return s.inner.ReplaySpooled(ctx, payload)
+}
@@ -0,0 +1,68 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+	middleware "github.com/rgdevment/spam-registry/internal/platform/http/middleware"
+	"github.com/rgdevment/spam-registry/internal/platform/tracing"
+	"github.com/rgdevment/spam-registry/internal/service"
+)
+
+type stubService struct {
+	service.Service
+}
+
+func (stubService) CheckRisk(ctx context.Context, phoneNumber string) (*domain.PhoneScore, error) {
+	return &domain.PhoneScore{PhoneNumber: phoneNumber, RiskLevel: domain.LevelSafe}, nil
+}
+
+func TestRequestSpanContinuesCallerTraceIntoService(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+
+	svc := tracing.NewService(stubService{})
+	r := chi.NewRouter()
+	r.Use(middleware.Tracing)
+	r.Get("/v1/check/{phone}", func(w http.ResponseWriter, r *http.Request) {
+		_, err := svc.CheckRisk(r.Context(), chi.URLParam(r, "phone"))
+		require.NoError(t, err)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/v1/check/+56912345678", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	inner, server := spans[0], spans[1]
+
+	assert.Equal(t, "GET /v1/check/{phone}", server.Name())
+	assert.Equal(t, traceID, server.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+
+	assert.Equal(t, "service.CheckRisk", inner.Name())
+	assert.Equal(t, server.SpanContext().SpanID(), inner.Parent().SpanID())
+
+	for _, span := range spans {
+		for _, attr := range span.Attributes() {
+			assert.NotContains(t, attr.Value.Emit(), "56912345678", "phone numbers must not reach span attributes")
+		}
+	}
+}
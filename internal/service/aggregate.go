@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+	"github.com/rgdevment/spam-registry/internal/service/sketch"
+)
+
+const (
+	halfLifeDays   = 110.0
+	velocityWindow = 7 * 24 * time.Hour
+
+	// Aggregates live as long as the newest raw report they summarise.
+	aggregateTTLSeconds  = 47304000
+	aggregateCASAttempts = 8
+)
+
+var ErrAggregateContention = errors.New("phone aggregate is being updated concurrently, retry later")
+
+var categoryWeights = map[domain.RiskCategory]float64{
+	domain.RiskFraud:     100.0,
+	domain.RiskPhishing:  90.0,
+	domain.RiskDebt:      40.0,
+	domain.RiskSpam:      20.0,
+	domain.RiskSales:     10.0,
+	domain.RiskAutoBlock: 0.0,
+}
+
+// Past the horizon even a FRAUD report is worth less than one point.
+var decayHorizon = time.Duration(halfLifeDays*math.Log2(categoryWeights[domain.RiskFraud])*24) * time.Hour
+
+func decayFactor(elapsed time.Duration) float64 {
+	days := elapsed.Hours() / 24.0
+	if days < 0 {
+		days = 0
+	}
+	return math.Pow(0.5, days/halfLifeDays)
+}
+
+// foldReport adds r to agg. The decayed sums are first moved forward to the
+// newest instant seen, so reports can be folded in any order.
+func foldReport(agg *domain.PhoneAggregate, reporters *sketch.HyperLogLog, r *domain.Report, now time.Time) {
+	agg.TotalReports++
+	if agg.CountryCode == "" {
+		agg.CountryCode = r.CountryCode
+	}
+
+	if r.Category == domain.RiskAutoBlock {
+		if now.Sub(r.CreatedAt) < velocityWindow {
+			agg.AutoBlockDays[domain.DayBucket(r.CreatedAt)]++
+		}
+		pruneAutoBlockDays(agg, now)
+		return
+	}
+
+	if r.CreatedAt.After(agg.LastHumanActivity) {
+		agg.LastHumanActivity = r.CreatedAt
+	}
+
+	if r.CreatedAt.After(agg.DecayedAt) {
+		if !agg.DecayedAt.IsZero() {
+			factor := decayFactor(r.CreatedAt.Sub(agg.DecayedAt))
+			for cat := range agg.CategoryDecay {
+				agg.CategoryDecay[cat] *= factor
+			}
+		}
+		agg.DecayedAt = r.CreatedAt
+	}
+	agg.CategoryDecay[r.Category] += decayFactor(agg.DecayedAt.Sub(r.CreatedAt))
+
+	// Rotation-window reports are counted under the previous key, the one
+	// the reporter's earlier reports carry. Reports made only under the new
+	// key count as a new reporter until RehashReporters rebuilds the sketch.
+	if r.ReporterPrevHash != "" {
+		reporters.Add(reporterIdentity(r.ReporterPrevKeyVersion, r.ReporterPrevHash))
+	} else {
+		reporters.Add(reporterIdentity(r.ReporterKeyVersion, r.ReporterHash))
+	}
+}
+
+// recentAutoBlocks counts AUTO_BLOCK reports of today and the six days before.
+func recentAutoBlocks(agg *domain.PhoneAggregate, now time.Time) int {
+	oldest := domain.DayBucket(now.Add(-velocityWindow + 24*time.Hour))
+	count := 0
+	for day, n := range agg.AutoBlockDays {
+		if day >= oldest {
+			count += n
+		}
+	}
+	return count
+}
+
+func pruneAutoBlockDays(agg *domain.PhoneAggregate, now time.Time) {
+	oldest := domain.DayBucket(now.Add(-velocityWindow + 24*time.Hour))
+	for day := range agg.AutoBlockDays {
+		if day < oldest {
+			delete(agg.AutoBlockDays, day)
+		}
+	}
+}
+
+// recordReport folds a freshly saved report into the aggregate of its
+// number, retrying on concurrent writers.
+func (s *reportService) recordReport(ctx context.Context, r *domain.Report) error {
+	for attempt := 0; attempt < aggregateCASAttempts; attempt++ {
+		agg, err := s.repo.GetAggregate(ctx, r.PhoneNumber)
+		if err != nil {
+			return err
+		}
+
+		if agg.Version == 0 {
+			// First aggregate of the number: backfill from the history,
+			// which already holds r.
+			_, err := s.rebuildAggregate(ctx, r.PhoneNumber)
+			return err
+		}
+
+		reporters, err := sketch.FromBytes(agg.ReporterSketch)
+		if err != nil {
+			return err
+		}
+		foldReport(agg, reporters, r, time.Now().UTC())
+		agg.ReporterSketch = reporters.Bytes()
+
+		swapped, err := s.repo.CompareAndSwapAggregate(ctx, agg, aggregateTTLSeconds)
+		if err != nil || swapped {
+			return err
+		}
+	}
+
+	return ErrAggregateContention
+}
+
+// foldHistory builds the aggregate of a number from the reports within the
+// decay horizon, without storing it.
+func (s *reportService) foldHistory(ctx context.Context, phoneNumber string, now time.Time) (*domain.PhoneAggregate, error) {
+	agg := domain.NewPhoneAggregate(phoneNumber)
+	reporters := sketch.NewHyperLogLog()
+
+	for r, err := range s.repo.StreamRawReports(ctx, phoneNumber, ReportRange{Since: now.Add(-decayHorizon), PageSize: 500}) {
+		if err != nil {
+			return nil, err
+		}
+		foldReport(agg, reporters, r, now)
+	}
+
+	agg.ReporterSketch = reporters.Bytes()
+	return agg, nil
+}
+
+func (s *reportService) RebuildAggregate(ctx context.Context, phoneNumber string) error {
+	_, err := s.rebuildAggregate(ctx, phoneNumber)
+	return err
+}
+
+// rebuildAggregate recomputes the aggregate of a number from its history and
+// replaces the stored one. A report ingested while the very first aggregate
+// of a number is built may be counted twice; rebuilding again corrects it.
+func (s *reportService) rebuildAggregate(ctx context.Context, phoneNumber string) (*domain.PhoneAggregate, error) {
+	for attempt := 0; attempt < aggregateCASAttempts; attempt++ {
+		current, err := s.repo.GetAggregate(ctx, phoneNumber)
+		if err != nil {
+			return nil, err
+		}
+
+		fresh, err := s.foldHistory(ctx, phoneNumber, time.Now().UTC())
+		if err != nil {
+			return nil, err
+		}
+
+		if fresh.TotalReports == 0 {
+			return fresh, nil
+		}
+
+		fresh.Version = current.Version
+
+		swapped, err := s.repo.CompareAndSwapAggregate(ctx, fresh, aggregateTTLSeconds)
+		if err != nil {
+			return nil, err
+		}
+		if swapped {
+			return fresh, nil
+		}
+	}
+
+	return nil, ErrAggregateContention
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Reports written before key versioning existed were hashed with
+// APP_SALT_SECRET, which is loaded as this version.
+const LegacyKeyVersion = 1
+
+// Keyring holds the versioned secrets used to pseudonymise reporters. The
+// highest version is active; older versions are kept during a rotation window
+// so reports hashed with them can still be linked to new ones.
+type Keyring struct {
+	secrets  map[int]string
+	versions []int // descending
+}
+
+func NewKeyring(secrets map[int]string) (*Keyring, error) {
+	if len(secrets) == 0 {
+		return nil, errors.New("keyring: at least one secret is required")
+	}
+
+	k := &Keyring{secrets: make(map[int]string, len(secrets))}
+	for v, secret := range secrets {
+		if v < 1 {
+			return nil, fmt.Errorf("keyring: invalid version %d", v)
+		}
+		if secret == "" {
+			return nil, fmt.Errorf("keyring: secret for version %d is empty", v)
+		}
+		k.secrets[v] = secret
+		k.versions = append(k.versions, v)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(k.versions)))
+
+	return k, nil
+}
+
+// ParseKeyring reads a "version:secret" list such as "2:new_secret,1:old_secret".
+// When spec is empty the legacy single salt becomes version 1.
+func ParseKeyring(spec, legacySalt string) (*Keyring, error) {
+	if strings.TrimSpace(spec) == "" {
+		if legacySalt == "" {
+			return nil, errors.New("keyring: no reporter secret configured")
+		}
+		return NewKeyring(map[int]string{LegacyKeyVersion: legacySalt})
+	}
+
+	secrets := make(map[int]string)
+	for _, entry := range strings.Split(spec, ",") {
+		versionStr, secret, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			return nil, fmt.Errorf("keyring: entry %q must be version:secret", entry)
+		}
+		v, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("keyring: invalid version %q", versionStr)
+		}
+		if _, dup := secrets[v]; dup {
+			return nil, fmt.Errorf("keyring: version %d defined twice", v)
+		}
+		secrets[v] = secret
+	}
+
+	return NewKeyring(secrets)
+}
+
+func (k *Keyring) ActiveVersion() int {
+	return k.versions[0]
+}
+
+// PreviousVersion returns the newest retired version still in the keyring.
+func (k *Keyring) PreviousVersion() (int, bool) {
+	if len(k.versions) < 2 {
+		return 0, false
+	}
+	return k.versions[1], true
+}
+
+// Hash pseudonymises input with the secret of version, which must be in the
+// keyring.
+func (k *Keyring) Hash(version int, input string) (string, error) {
+	secret, ok := k.secrets[version]
+	if !ok {
+		return "", fmt.Errorf("keyring: no secret for version %d", version)
+	}
+
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(input))
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,60 @@
+package moderation
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+	"golang.org/x/text/unicode/norm"
+)
+
+var threatWords = []string{
+	"te voy a matar", "te mato", "los voy a matar", "te voy a buscar", "se donde vives",
+	"bomba", "kill you", "i will find you", "know where you live",
+}
+
+var profanityWords = []string{
+	"weon", "culiao", "conchetumare", "ctm", "puta", "mierda", "hijo de puta",
+	"pendejo", "cabron", "fuck", "shit", "bitch", "asshole",
+}
+
+// FlagWords flags a submission when any of the words or phrases appears as a
+// whole word, ignoring case and accents.
+func FlagWords(flag domain.ModerationFlag, words []string) Stage {
+	normalized := make([]string, 0, len(words))
+	for _, w := range words {
+		normalized = append(normalized, " "+normalize(w)+" ")
+	}
+
+	return StageFunc(func(ctx context.Context, sub *Submission) error {
+		text := " " + normalize(sub.Comment) + " "
+		for _, w := range normalized {
+			if strings.Contains(text, w) {
+				sub.Flag(flag)
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+// normalize lowercases, strips accents and collapses everything that is not a
+// letter or digit into single spaces.
+func normalize(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range norm.NFD.String(strings.ToLower(s)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			space = false
+		case !space:
+			b.WriteRune(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,66 @@
+package moderation
+
+import (
+	"context"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+)
+
+// Submission is the comment of a report on its way to storage. Stages may
+// rewrite Comment and append Flags.
+type Submission struct {
+	PhoneNumber string // E.164 number being reported
+	CountryCode string
+	Comment     string
+	Flags       []domain.ModerationFlag
+}
+
+func (s *Submission) Flag(flag domain.ModerationFlag) {
+	for _, f := range s.Flags {
+		if f == flag {
+			return
+		}
+	}
+	s.Flags = append(s.Flags, flag)
+}
+
+type Stage interface {
+	Apply(ctx context.Context, sub *Submission) error
+}
+
+type StageFunc func(ctx context.Context, sub *Submission) error
+
+func (f StageFunc) Apply(ctx context.Context, sub *Submission) error {
+	return f(ctx, sub)
+}
+
+type Pipeline struct {
+	stages []Stage
+}
+
+func NewPipeline(stages ...Stage) *Pipeline {
+	return &Pipeline{stages: stages}
+}
+
+// DefaultPipeline redacts PII first so flaggers never see raw identifiers.
+// Card numbers and RUTs run before phones because their digit runs can also
+// parse as phone numbers.
+func DefaultPipeline() *Pipeline {
+	return NewPipeline(
+		RedactEmails(),
+		RedactCardNumbers(),
+		RedactRUTs(),
+		RedactOtherPhones(),
+		FlagWords(domain.FlagThreat, threatWords),
+		FlagWords(domain.FlagProfanity, profanityWords),
+	)
+}
+
+func (p *Pipeline) Run(ctx context.Context, sub *Submission) error {
+	for _, stage := range p.stages {
+		if err := stage.Apply(ctx, sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package moderation_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+	"github.com/rgdevment/spam-registry/internal/service/moderation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultPipeline(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Comment  string
+		Expected string
+		Flags    []domain.ModerationFlag
+	}{
+		{"email", "escriban a juan.perez@gmail.com", "escriban a [EMAIL]", nil},
+		{"luhn valid card", "me pidió la tarjeta 4111 1111 1111 1111 ya", "me pidió la tarjeta [CARD] ya", nil},
+		{"luhn invalid digits kept", "folio 4111 1111 1111 1112", "folio 4111 1111 1111 1112", nil},
+		{"valid rut", "mi RUT 12.345.678-5 lo pidió", "mi RUT [NATIONAL_ID] lo pidió", nil},
+		{"invalid rut kept", "codigo 12.345.678-9", "codigo 12.345.678-9", nil},
+		{"other phone redacted", "dijo que llamara al +56 9 2222 2222", "dijo que llamara al [PHONE]", nil},
+		{"reported phone kept", "este +56 9 8765 4321 llama todo el día", "este +56 9 8765 4321 llama todo el día", nil},
+		{"threat", "Te voy a MATAR si no pagas", "Te voy a MATAR si no pagas", []domain.ModerationFlag{domain.FlagThreat}},
+		{"profanity ignores accents", "que cabrón", "que cabrón", []domain.ModerationFlag{domain.FlagProfanity}},
+		{"substring is not a word", "computadora", "computadora", nil},
+	}
+
+	pipeline := moderation.DefaultPipeline()
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			sub := &moderation.Submission{PhoneNumber: "+56987654321", CountryCode: "CL", Comment: tc.Comment}
+			require.NoError(t, pipeline.Run(context.Background(), sub))
+			assert.Equal(t, tc.Expected, sub.Comment)
+			assert.Equal(t, tc.Flags, sub.Flags)
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package moderation
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+const (
+	emailPlaceholder = "[EMAIL]"
+	cardPlaceholder  = "[CARD]"
+	idPlaceholder    = "[NATIONAL_ID]"
+	phonePlaceholder = "[PHONE]"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	cardPattern  = regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`)
+	rutPattern   = regexp.MustCompile(`\b\d{1,2}(?:\.?\d{3}){2}-[\dkK]\b`)
+	phonePattern = regexp.MustCompile(`\+?\d[\d \-().]{5,18}\d`)
+)
+
+func RedactEmails() Stage {
+	return StageFunc(func(ctx context.Context, sub *Submission) error {
+		sub.Comment = emailPattern.ReplaceAllString(sub.Comment, emailPlaceholder)
+		return nil
+	})
+}
+
+// RedactCardNumbers only redacts 13-19 digit runs that pass the Luhn check,
+// leaving other long numbers to the phone stage.
+func RedactCardNumbers() Stage {
+	return StageFunc(func(ctx context.Context, sub *Submission) error {
+		sub.Comment = cardPattern.ReplaceAllStringFunc(sub.Comment, func(match string) string {
+			if luhnValid(digitsOf(match)) {
+				return cardPlaceholder
+			}
+			return match
+		})
+		return nil
+	})
+}
+
+// RedactRUTs redacts Chilean RUT/RUN numbers whose check digit is valid.
+func RedactRUTs() Stage {
+	return StageFunc(func(ctx context.Context, sub *Submission) error {
+		sub.Comment = rutPattern.ReplaceAllStringFunc(sub.Comment, func(match string) string {
+			if rutValid(match) {
+				return idPlaceholder
+			}
+			return match
+		})
+		return nil
+	})
+}
+
+// RedactOtherPhones redacts valid phone numbers other than the one being
+// reported, which is public by definition.
+func RedactOtherPhones() Stage {
+	return StageFunc(func(ctx context.Context, sub *Submission) error {
+		sub.Comment = phonePattern.ReplaceAllStringFunc(sub.Comment, func(match string) string {
+			num, err := phonenumbers.Parse(match, sub.CountryCode)
+			if err != nil || !phonenumbers.IsValidNumber(num) {
+				return match
+			}
+			if phonenumbers.Format(num, phonenumbers.E164) == sub.PhoneNumber {
+				return match
+			}
+			return phonePlaceholder
+		})
+		return nil
+	})
+}
+
+func digitsOf(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func luhnValid(digits string) bool {
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+func rutValid(rut string) bool {
+	body, check, ok := strings.Cut(strings.ToUpper(rut), "-")
+	if !ok {
+		return false
+	}
+	body = digitsOf(body)
+
+	sum, factor := 0, 2
+	for i := len(body) - 1; i >= 0; i-- {
+		sum += int(body[i]-'0') * factor
+		factor++
+		if factor > 7 {
+			factor = 2
+		}
+	}
+
+	expected := 11 - sum%11
+	switch expected {
+	case 11:
+		return check == "0"
+	case 10:
+		return check == "K"
+	default:
+		return check == string(rune('0'+expected))
+	}
+}
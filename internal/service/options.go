@@ -0,0 +1,58 @@
+package service
+
+import (
+	"time"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+	"github.com/rgdevment/spam-registry/internal/service/moderation"
+)
+
+type Option func(*reportService)
+
+// WithModeration replaces the default comment moderation pipeline.
+func WithModeration(p *moderation.Pipeline) Option {
+	return func(s *reportService) {
+		s.moderation = p
+	}
+}
+
+// ScoreSnapshot is a local copy of the scores, taken at some point in the
+// past. ok is false until a snapshot has been loaded.
+type ScoreSnapshot interface {
+	Lookup(phoneNumber string) (score *domain.PhoneScore, takenAt time.Time, ok bool)
+}
+
+// WithSnapshot makes CheckRisk answer from snap, flagged stale, when the
+// repository lookup fails.
+func WithSnapshot(snap ScoreSnapshot) Option {
+	return func(s *reportService) {
+		s.snapshot = snap
+	}
+}
+
+// WithSpool makes IngestReport accept reports the repository fails to save
+// by writing them to sp. They are stored later through ReplaySpooled. When
+// the repository encrypts comments, sealer must seal them with the same keys;
+// nil keeps spooled comments as they are.
+func WithSpool(sp ReportSpool, sealer CommentSealer) Option {
+	return func(s *reportService) {
+		s.spool = sp
+		s.sealer = sealer
+	}
+}
+
+// Observer is told about service events worth measuring.
+type Observer interface {
+	ReportIngested(category domain.RiskCategory, countryCode string)
+	ReportSpooled(countryCode string)
+	AggregateUpdateFailed(countryCode string)
+	RiskRecalculated(elapsed time.Duration, from, to domain.RiskLevel)
+}
+
+// WithObserver reports ingestion and recalculation events to o. It costs
+// one extra score read per recalculation, to know the level it replaces.
+func WithObserver(o Observer) Option {
+	return func(s *reportService) {
+		s.observer = o
+	}
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+)
+
+const (
+	DefaultPublicReportLimit = 20
+	MaxPublicReportLimit     = 100
+
+	commentExcerptRunes = 280
+)
+
+var ErrInvalidPagination = errors.New("invalid pagination: page must be >= 1 and limit between 1 and 100")
+
+// ListPublicReports returns the moderated comments of a number, newest first.
+// Only reports that passed moderation and carry a comment are listed.
+func (s *reportService) ListPublicReports(ctx context.Context, phoneNumber, lang string, page, limit int) (*domain.PublicReportPage, error) {
+	if page < 1 || limit < 1 || limit > MaxPublicReportLimit {
+		return nil, ErrInvalidPagination
+	}
+
+	lang = strings.ToLower(lang)
+	now := time.Now().UTC()
+	offset := (page - 1) * limit
+
+	result := &domain.PublicReportPage{
+		Reports: []*domain.PublicReport{},
+		Page:    page,
+		Limit:   limit,
+	}
+
+	// The read stops one match past the requested page; filtered reports make
+	// the fetch size only a hint.
+	rng := ReportRange{PageSize: min(offset+limit+1, 500)}
+
+	matched := 0
+	for r, err := range s.repo.StreamRawReports(ctx, phoneNumber, rng) {
+		if err != nil {
+			return nil, err
+		}
+		if r.ModerationStatus != domain.ModerationPublished || r.Comment == "" || r.Category == domain.RiskAutoBlock {
+			continue
+		}
+		if lang != "" && r.Lang != lang {
+			continue
+		}
+
+		matched++
+		if matched <= offset {
+			continue
+		}
+		if len(result.Reports) == limit {
+			result.HasMore = true
+			break
+		}
+
+		result.Reports = append(result.Reports, &domain.PublicReport{
+			Category:  r.Category,
+			AgeBucket: domain.AgeBucketFor(r.CreatedAt, now),
+			Comment:   excerpt(r.Comment),
+			Lang:      r.Lang,
+		})
+	}
+
+	return result, nil
+}
+
+func excerpt(comment string) string {
+	if utf8.RuneCountInString(comment) <= commentExcerptRunes {
+		return comment
+	}
+	runes := []rune(comment)
+	return strings.TrimSpace(string(runes[:commentExcerptRunes])) + "…"
+}
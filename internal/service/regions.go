@@ -0,0 +1,24 @@
+package service
+
+import (
+	"sort"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+// UnknownRegion is the country code of a number whose region is not known.
+const UnknownRegion = "XX"
+
+// ThreatRegions lists, sorted, every country code the threat index can file
+// a number under: the regions libphonenumber supports, UnknownRegion and
+// 001 for non-geographic numbers such as +800. Anything that walks the
+// index by country must walk all of them.
+func ThreatRegions() []string {
+	regions := make([]string, 0, len(phonenumbers.GetSupportedRegions())+2)
+	for region := range phonenumbers.GetSupportedRegions() {
+		regions = append(regions, region)
+	}
+	regions = append(regions, UnknownRegion, phonenumbers.REGION_CODE_FOR_NON_GEO_ENTITY)
+	sort.Strings(regions)
+	return regions
+}
@@ -2,31 +2,49 @@ package service
 
 import (
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"log/slog"
 	"math"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/nyaruka/phonenumbers"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+
 	"github.com/rgdevment/spam-registry/internal/domain"
+	"github.com/rgdevment/spam-registry/internal/platform/logging"
+	"github.com/rgdevment/spam-registry/internal/service/moderation"
+	"github.com/rgdevment/spam-registry/internal/service/sketch"
 )
 
+var ErrInvalidHashPrefix = errors.New("invalid hash prefix: expected 4 to 8 hex characters of SHA-256(E.164)")
+
 type reportService struct {
 	repo       Repository
-	saltSecret string
+	keys       *Keyring
+	moderation *moderation.Pipeline
+	snapshot   ScoreSnapshot
+	spool      ReportSpool
+	sealer     CommentSealer
+	observer   Observer
 }
 
-func NewReportService(repo Repository, salt string) Service {
-	return &reportService{
+func NewReportService(repo Repository, keys *Keyring, opts ...Option) Service {
+	s := &reportService{
 		repo:       repo,
-		saltSecret: salt,
+		keys:       keys,
+		moderation: moderation.DefaultPipeline(),
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
-func (s *reportService) IngestReport(ctx context.Context, rawPhone, rawReporter, category, comment string) error {
+func (s *reportService) IngestReport(ctx context.Context, rawPhone, rawReporter, category, comment, lang string) error {
 	num, err := phonenumbers.Parse(rawPhone, "")
 	if err != nil {
 		return errors.New("invalid phone format: ensure it includes country code (e.g. +569...)")
@@ -46,7 +64,11 @@ func (s *reportService) IngestReport(ctx context.Context, rawPhone, rawReporter,
 	if rawReporter == "" {
 		return errors.New("reporter identity is missing")
 	}
-	reporterHash := s.generateHash(rawReporter)
+	activeVersion := s.keys.ActiveVersion()
+	reporterHash, err := s.keys.Hash(activeVersion, rawReporter)
+	if err != nil {
+		return err
+	}
 
 	riskCat := domain.RiskCategory(strings.ToUpper(category))
 
@@ -57,135 +79,298 @@ func (s *reportService) IngestReport(ctx context.Context, rawPhone, rawReporter,
 		riskCat,
 		comment,
 	)
+	report.ReporterKeyVersion = activeVersion
+	report.Lang = strings.ToLower(lang)
 
-	return s.repo.SaveRawReport(ctx, report)
+	if prevVersion, ok := s.keys.PreviousVersion(); ok {
+		prevHash, err := s.keys.Hash(prevVersion, rawReporter)
+		if err != nil {
+			return err
+		}
+		report.ReporterPrevHash = prevHash
+		report.ReporterPrevKeyVersion = prevVersion
+	}
+
+	sub := &moderation.Submission{
+		PhoneNumber: cleanPhone,
+		CountryCode: isoRegion,
+		Comment:     comment,
+	}
+	if err := s.moderation.Run(ctx, sub); err != nil {
+		return err
+	}
+
+	report.Comment = sub.Comment
+	report.ModerationStatus = domain.ModerationPublished
+	if len(sub.Flags) > 0 {
+		report.ModerationStatus = domain.ModerationPending
+	}
+
+	if err := s.repo.SaveRawReport(ctx, report); err != nil {
+		if s.spool == nil {
+			return err
+		}
+		if err := s.spoolReport(ctx, report, sub.Flags, err); err != nil {
+			return err
+		}
+		if s.observer != nil {
+			s.observer.ReportSpooled(isoRegion)
+			s.observer.ReportIngested(riskCat, isoRegion)
+		}
+		return nil
+	}
+
+	if err := s.recordReport(ctx, report); err != nil {
+		// The report is stored: failing the ingest now would only have it
+		// sent again. The aggregate misses it until it is rebuilt.
+		slog.WarnContext(ctx, "⚠️  Aggregate update failed; run worker -rebuild for the number",
+			logging.Phone("phone", cleanPhone), logging.Err(err))
+		if s.observer != nil {
+			s.observer.AggregateUpdateFailed(isoRegion)
+		}
+	}
+	if err := s.enqueueModeration(ctx, report, sub.Flags); err != nil {
+		return err
+	}
+	if s.observer != nil {
+		s.observer.ReportIngested(riskCat, isoRegion)
+	}
+	return nil
+}
+
+// indexReport runs what follows a replayed report: the aggregate update and,
+// for flagged comments, the moderation queue. With reindex set the report
+// may have been indexed already: the aggregate is rebuilt from the history,
+// which holds the report once, instead of folding it in again.
+func (s *reportService) indexReport(ctx context.Context, report *domain.Report, flags []domain.ModerationFlag, reindex bool) error {
+	if reindex {
+		if _, err := s.rebuildAggregate(ctx, report.PhoneNumber); err != nil {
+			return err
+		}
+	} else if err := s.recordReport(ctx, report); err != nil {
+		return err
+	}
+	return s.enqueueModeration(ctx, report, flags)
+}
+
+// enqueueModeration queues a report with flagged comments for review.
+func (s *reportService) enqueueModeration(ctx context.Context, report *domain.Report, flags []domain.ModerationFlag) error {
+	if report.ModerationStatus != domain.ModerationPending {
+		return nil
+	}
+
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	// The item is enqueued as of the report's creation, so its key depends
+	// on the report alone and enqueueing it twice replaces the first.
+	return s.repo.EnqueueModeration(ctx, &domain.ModerationItem{
+		ReportID:        report.ID,
+		PhoneNumber:     report.PhoneNumber,
+		CountryCode:     report.CountryCode,
+		ReportCreatedAt: report.CreatedAt,
+		Flags:           flags,
+		EnqueuedAt:      report.CreatedAt,
+		TraceContext:    carrier,
+	})
 }
 
 func (s *reportService) CheckRisk(ctx context.Context, phoneNumber string) (*domain.PhoneScore, error) {
-	return s.repo.GetScore(ctx, phoneNumber)
+	score, err := s.repo.GetScore(ctx, phoneNumber)
+	if err == nil || s.snapshot == nil || ctx.Err() != nil {
+		return score, err
+	}
+
+	// Call screening fails open: the last known blocklist beats an error.
+	stale, takenAt, ok := s.snapshot.Lookup(phoneNumber)
+	if !ok {
+		return nil, err
+	}
+	stale.Stale = true
+	stale.SnapshotAt = takenAt
+	return stale, nil
 }
 
-func (s *reportService) generateHash(input string) string {
-	h := hmac.New(sha256.New, []byte(s.saltSecret))
-	h.Write([]byte(input))
-	return hex.EncodeToString(h.Sum(nil))
+func (s *reportService) CheckRiskByHashPrefix(ctx context.Context, prefix string) ([]*domain.HashedScore, error) {
+	prefix = strings.ToLower(prefix)
+
+	if len(prefix) < domain.MinHashPrefixLength || len(prefix) > domain.MaxHashPrefixLength {
+		return nil, ErrInvalidHashPrefix
+	}
+	if _, err := hex.DecodeString(prefix + strings.Repeat("0", len(prefix)%2)); err != nil {
+		return nil, ErrInvalidHashPrefix
+	}
+
+	return s.repo.GetScoresByHashPrefix(ctx, prefix)
 }
 
 func (s *reportService) CalculateAndSaveRisk(ctx context.Context, phoneNumber string) error {
-	history, err := s.repo.GetRawReports(ctx, phoneNumber)
+	if s.observer == nil {
+		_, err := s.recalculate(ctx, phoneNumber)
+		return err
+	}
+
+	start := time.Now()
+	from := domain.LevelSafe
+	if current, err := s.repo.GetScore(ctx, phoneNumber); err == nil && current != nil {
+		from = current.RiskLevel
+	}
+
+	to, err := s.recalculate(ctx, phoneNumber)
 	if err != nil {
 		return err
 	}
+	s.observer.RiskRecalculated(time.Since(start), from, to)
+	return nil
+}
 
-	if len(history) == 0 {
-		return s.repo.DeleteScore(ctx, phoneNumber, "XX")
+// recalculate scores the number from its aggregate and returns the level it
+// was left at; SAFE when its score was removed.
+func (s *reportService) recalculate(ctx context.Context, phoneNumber string) (domain.RiskLevel, error) {
+	const OneYearSeconds = 31536000
+
+	agg, err := s.repo.GetAggregate(ctx, phoneNumber)
+	if err != nil {
+		return "", err
+	}
+	if agg.Version == 0 {
+		// Numbers reported before aggregates existed are backfilled once.
+		if agg, err = s.rebuildAggregate(ctx, phoneNumber); err != nil {
+			return "", err
+		}
 	}
 
-	const (
-		HalfLifeDays   = 110.0
-		OneYearSeconds = 31536000
-	)
+	if agg.TotalReports == 0 {
+		// The reports expired; take the country from the score being removed
+		// so its threat index entry goes too.
+		countryCode := UnknownRegion
+		if current, err := s.repo.GetScore(ctx, phoneNumber); err == nil && current != nil && current.CountryCode != "" {
+			countryCode = current.CountryCode
+		}
+		return domain.LevelSafe, s.repo.DeleteScore(ctx, phoneNumber, countryCode)
+	}
 
-	weights := map[domain.RiskCategory]float64{
-		domain.RiskFraud:     100.0,
-		domain.RiskPhishing:  90.0,
-		domain.RiskDebt:      40.0,
-		domain.RiskSpam:      20.0,
-		domain.RiskSales:     10.0,
-		domain.RiskAutoBlock: 0.0,
+	x, err := explainAggregate(agg, time.Now().UTC())
+	if err != nil {
+		return "", err
+	}
+	if x.Dropped {
+		return domain.LevelSafe, s.repo.DeleteScore(ctx, phoneNumber, x.CountryCode)
 	}
 
-	var totalRawScore float64
-	var lastHumanActivity time.Time
-	var autoBlockCount int
+	lastActivity := x.LastHumanActivity
+	if x.VelocityFloor {
+		lastActivity = x.Now
+	}
+	newScore := &domain.PhoneScore{
+		PhoneNumber:      phoneNumber,
+		CountryCode:      x.CountryCode,
+		Score:            x.Score,
+		RiskLevel:        x.RiskLevel,
+		LastActivity:     lastActivity,
+		VelocityHitCount: x.AutoBlocks,
+		TotalReports:     x.TotalReports,
+	}
 
-	uniqueReporters := make(map[string]bool)
+	if err := s.repo.UpsertScore(ctx, newScore, OneYearSeconds); err != nil {
+		return "", err
+	}
+	return x.RiskLevel, s.repo.UpsertCountryThreat(ctx, newScore, OneYearSeconds)
+}
 
-	countryCode := history[0].CountryCode
+// ExplainRisk scores the number like CalculateAndSaveRisk would, without
+// writing anything, and returns every term of the computation.
+func (s *reportService) ExplainRisk(ctx context.Context, phoneNumber string) (*domain.ScoreExplanation, error) {
 	now := time.Now().UTC()
 
-	for _, r := range history {
-		if r.Category == domain.RiskAutoBlock {
-			if now.Sub(r.CreatedAt).Hours() < 24*7 {
-				autoBlockCount++
-			}
-			continue
+	agg, err := s.repo.GetAggregate(ctx, phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	if agg.Version == 0 {
+		if agg, err = s.foldHistory(ctx, phoneNumber, now); err != nil {
+			return nil, err
 		}
+	}
 
-		uniqueReporters[r.ReporterHash] = true
+	if agg.TotalReports == 0 {
+		return &domain.ScoreExplanation{PhoneNumber: phoneNumber, Now: now, RiskLevel: domain.LevelSafe, Dropped: true}, nil
+	}
+	return explainAggregate(agg, now)
+}
 
-		if r.CreatedAt.After(lastHumanActivity) {
-			lastHumanActivity = r.CreatedAt
-		}
+// explainAggregate is the scoring formula: the decayed, weighted category
+// sums, scaled by reporter consensus, with a floor for numbers that many
+// devices auto-block.
+func explainAggregate(agg *domain.PhoneAggregate, now time.Time) (*domain.ScoreExplanation, error) {
+	const MaxScore = 100.0
+
+	x := &domain.ScoreExplanation{
+		PhoneNumber:       agg.PhoneNumber,
+		CountryCode:       agg.CountryCode,
+		Now:               now,
+		AutoBlocks:        recentAutoBlocks(agg, now),
+		TotalReports:      agg.TotalReports,
+		LastHumanActivity: agg.LastHumanActivity,
+	}
 
-		weight := weights[r.Category]
-		elapsedDays := now.Sub(r.CreatedAt).Hours() / 24.0
-		if elapsedDays < 0 {
-			elapsedDays = 0
+	decay := decayFactor(now.Sub(agg.DecayedAt))
+	for cat, sum := range agg.CategoryDecay {
+		c := domain.CategoryContribution{
+			Category:       cat,
+			DecayedReports: sum * decay,
+			Weight:         categoryWeights[cat],
 		}
-
-		decayFactor := math.Pow(0.5, elapsedDays/HalfLifeDays)
-		totalRawScore += weight * decayFactor
+		c.Points = c.Weight * c.DecayedReports
+		x.RawScore += c.Points
+		x.Categories = append(x.Categories, c)
 	}
+	sort.Slice(x.Categories, func(i, j int) bool { return x.Categories[i].Points > x.Categories[j].Points })
 
-	reportersCount := len(uniqueReporters)
-	var consensusFactor float64
+	reporters, err := sketch.FromBytes(agg.ReporterSketch)
+	if err != nil {
+		return nil, err
+	}
 
+	x.Reporters = reporters.Estimate()
 	switch {
-	case reportersCount == 1:
-		consensusFactor = 0.10
-	case reportersCount == 2:
-		consensusFactor = 0.20
-	case reportersCount == 3:
-		consensusFactor = 0.30
-	case reportersCount == 4:
-		consensusFactor = 0.50
-	case reportersCount == 5:
-		consensusFactor = 0.70
+	case x.Reporters == 1:
+		x.ConsensusFactor = 0.10
+	case x.Reporters == 2:
+		x.ConsensusFactor = 0.20
+	case x.Reporters == 3:
+		x.ConsensusFactor = 0.30
+	case x.Reporters == 4:
+		x.ConsensusFactor = 0.50
+	case x.Reporters == 5:
+		x.ConsensusFactor = 0.70
 	default:
-		consensusFactor = 1.00
+		x.ConsensusFactor = 1.00
 	}
 
-	finalScore := totalRawScore * consensusFactor
+	finalScore := x.RawScore * x.ConsensusFactor
 
-	effectiveLastActivity := lastHumanActivity
-	if autoBlockCount > 10 {
-		effectiveLastActivity = now
+	if x.AutoBlocks > 10 {
+		x.VelocityFloor = true
 		if finalScore < 25 {
 			finalScore = 25.0
 		}
 	}
 
-	if finalScore > 100.0 {
-		finalScore = 100.0
+	if finalScore > MaxScore {
+		finalScore = MaxScore
 	}
 
-	var level domain.RiskLevel
 	switch {
 	case finalScore >= 60:
-		level = domain.LevelCritical
+		x.RiskLevel = domain.LevelCritical
 	case finalScore >= 20:
-		level = domain.LevelWarning
+		x.RiskLevel = domain.LevelWarning
 	default:
-		level = domain.LevelSafe
-	}
-
-	if finalScore < 5.0 {
-		return s.repo.DeleteScore(ctx, phoneNumber, countryCode)
-	}
-
-	newScore := &domain.PhoneScore{
-		PhoneNumber:      phoneNumber,
-		CountryCode:      countryCode,
-		Score:            math.Round(finalScore*100) / 100,
-		RiskLevel:        level,
-		LastActivity:     effectiveLastActivity,
-		VelocityHitCount: autoBlockCount,
-		TotalReports:     len(history),
+		x.RiskLevel = domain.LevelSafe
 	}
 
-	if err := s.repo.UpsertScore(ctx, newScore, OneYearSeconds); err != nil {
-		return err
-	}
-	return s.repo.UpsertCountryThreat(ctx, newScore, OneYearSeconds)
+	x.Dropped = finalScore < 5.0
+	x.Score = math.Round(finalScore*100) / 100
+	return x, nil
 }
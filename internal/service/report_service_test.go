@@ -2,7 +2,14 @@ package service_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"iter"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -10,17 +17,25 @@ import (
 	"github.com/rgdevment/spam-registry/internal/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type MockRepo struct {
-	reports []*domain.Report
-	scores  map[string]*domain.PhoneScore
+	reports    []*domain.Report
+	scores     map[string]*domain.PhoneScore
+	moderation []*domain.ModerationItem
+	aggregates map[string]domain.PhoneAggregate
+
+	streams int
 }
 
 func NewMockRepo() *MockRepo {
 	return &MockRepo{
-		reports: []*domain.Report{},
-		scores:  make(map[string]*domain.PhoneScore),
+		reports:    []*domain.Report{},
+		scores:     make(map[string]*domain.PhoneScore),
+		aggregates: make(map[string]domain.PhoneAggregate),
 	}
 }
 
@@ -39,6 +54,59 @@ func (m *MockRepo) GetRawReports(ctx context.Context, phone string) ([]*domain.R
 	return result, nil
 }
 
+func (m *MockRepo) StreamRawReports(ctx context.Context, phone string, rng service.ReportRange) iter.Seq2[*domain.Report, error] {
+	var result []*domain.Report
+	for _, r := range m.reports {
+		if r.PhoneNumber == phone && rng.Contains(r.CreatedAt) {
+			result = append(result, r)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].CreatedAt.After(result[j].CreatedAt) })
+	m.streams++
+
+	return func(yield func(*domain.Report, error) bool) {
+		for _, r := range result {
+			if !yield(r, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (m *MockRepo) GetAggregate(ctx context.Context, phone string) (*domain.PhoneAggregate, error) {
+	stored, ok := m.aggregates[phone]
+	if !ok {
+		return domain.NewPhoneAggregate(phone), nil
+	}
+
+	agg := stored
+	agg.CategoryDecay = maps.Clone(stored.CategoryDecay)
+	agg.AutoBlockDays = maps.Clone(stored.AutoBlockDays)
+	return &agg, nil
+}
+
+func (m *MockRepo) CompareAndSwapAggregate(ctx context.Context, agg *domain.PhoneAggregate, ttl int) (bool, error) {
+	if m.aggregates[agg.PhoneNumber].Version != agg.Version {
+		return false, nil
+	}
+
+	agg.Version++
+	stored := *agg
+	stored.CategoryDecay = maps.Clone(agg.CategoryDecay)
+	stored.AutoBlockDays = maps.Clone(agg.AutoBlockDays)
+	m.aggregates[agg.PhoneNumber] = stored
+	return true, nil
+}
+
+func (m *MockRepo) UpdateReporterHash(ctx context.Context, r *domain.Report) error {
+	for i, existing := range m.reports {
+		if existing.ID == r.ID {
+			m.reports[i] = r
+		}
+	}
+	return nil
+}
+
 func (m *MockRepo) UpsertScore(ctx context.Context, s *domain.PhoneScore, ttl int) error {
 	m.scores[s.PhoneNumber] = s
 	return nil
@@ -48,6 +116,10 @@ func (m *MockRepo) UpsertCountryThreat(ctx context.Context, s *domain.PhoneScore
 	return nil
 }
 
+func (m *MockRepo) ListCountryThreats(ctx context.Context, country string) ([]*domain.PhoneScore, error) {
+	return nil, nil
+}
+
 func (m *MockRepo) DeleteScore(ctx context.Context, phone, country string) error {
 	delete(m.scores, phone)
 	return nil
@@ -57,7 +129,324 @@ func (m *MockRepo) GetScore(ctx context.Context, phone string) (*domain.PhoneSco
 	if s, exists := m.scores[phone]; exists {
 		return s, nil
 	}
-	return nil, nil
+	return &domain.PhoneScore{PhoneNumber: phone, RiskLevel: domain.LevelSafe}, nil
+}
+
+func (m *MockRepo) GetScoresByHashPrefix(ctx context.Context, prefix string) ([]*domain.HashedScore, error) {
+	result := []*domain.HashedScore{}
+	for phone, s := range m.scores {
+		if hash := domain.HashPhone(phone); strings.HasPrefix(hash, prefix) {
+			result = append(result, &domain.HashedScore{PhoneHash: hash, Score: s.Score, RiskLevel: s.RiskLevel})
+		}
+	}
+	return result, nil
+}
+
+func TestIngestModeratesComments(t *testing.T) {
+	repo := NewMockRepo()
+	svc := service.NewReportService(repo, mustKeyring(t, "", "secret_salt"))
+	ctx := context.Background()
+
+	require.NoError(t, svc.IngestReport(ctx, "+56987654321", "user_A", "FRAUD",
+		"Dijo ser del banco, pidió mi RUT 12.345.678-5 y escribir a soporte@banco-falso.cl", "es"))
+	require.NoError(t, svc.IngestReport(ctx, "+56987654321", "user_B", "FRAUD",
+		"Me dijo que sabe donde vivo y que te voy a matar", "es"))
+
+	require.Len(t, repo.reports, 2)
+
+	clean := repo.reports[0]
+	assert.Equal(t, domain.ModerationPublished, clean.ModerationStatus)
+	assert.NotContains(t, clean.Comment, "12.345.678-5")
+	assert.NotContains(t, clean.Comment, "soporte@banco-falso.cl")
+
+	flagged := repo.reports[1]
+	assert.Equal(t, domain.ModerationPending, flagged.ModerationStatus)
+	require.Len(t, repo.moderation, 1)
+	assert.Equal(t, flagged.ID, repo.moderation[0].ReportID)
+	assert.Equal(t, []domain.ModerationFlag{domain.FlagThreat}, repo.moderation[0].Flags)
+
+	public, err := svc.ListPublicReports(ctx, "+56987654321", "ES", 1, 10)
+	require.NoError(t, err)
+	require.Len(t, public.Reports, 1, "flagged comments must not be published")
+	assert.Equal(t, clean.Comment, public.Reports[0].Comment)
+	assert.Equal(t, domain.AgeLastDay, public.Reports[0].AgeBucket)
+	assert.False(t, public.HasMore)
+
+	public, err = svc.ListPublicReports(ctx, "+56987654321", "en", 1, 10)
+	require.NoError(t, err)
+	assert.Empty(t, public.Reports)
+
+	_, err = svc.ListPublicReports(ctx, "+56987654321", "", 0, 10)
+	assert.ErrorIs(t, err, service.ErrInvalidPagination)
+}
+
+type downForWrites struct {
+	*MockRepo
+	down bool
+}
+
+func (r *downForWrites) SaveRawReport(ctx context.Context, report *domain.Report) error {
+	if r.down {
+		return errors.New("no hosts available")
+	}
+	return r.MockRepo.SaveRawReport(ctx, report)
+}
+
+type memorySpool struct {
+	entries [][]byte
+}
+
+func (m *memorySpool) Append(payload []byte) error {
+	m.entries = append(m.entries, append([]byte(nil), payload...))
+	return nil
+}
+
+func TestIngestSpoolsReportsWhileStoreIsDown(t *testing.T) {
+	ctx := context.Background()
+	repo := &downForWrites{MockRepo: NewMockRepo(), down: true}
+	spooled := &memorySpool{}
+	svc := service.NewReportService(repo, mustKeyring(t, "", "secret_salt"), service.WithSpool(spooled, nil))
+
+	require.NoError(t, svc.IngestReport(ctx, "+56987654321", "user_A", "FRAUD", "", ""))
+	require.NoError(t, svc.IngestReport(ctx, "+56987654321", "user_B", "FRAUD",
+		"Me dijo que sabe donde vivo y que te voy a matar", "es"))
+	assert.Empty(t, repo.reports)
+	require.Len(t, spooled.entries, 2)
+
+	repo.down = false
+	for range 2 {
+		// A second pass stands for a replay that crashed before acknowledging.
+		for _, payload := range spooled.entries {
+			require.NoError(t, svc.ReplaySpooled(ctx, payload))
+		}
+	}
+
+	require.Len(t, repo.reports, 2, "replays are idempotent on the report ID")
+	require.Len(t, repo.moderation, 1, "flags survive the spool")
+	assert.Equal(t, []domain.ModerationFlag{domain.FlagThreat}, repo.moderation[0].Flags)
+
+	agg, err := repo.GetAggregate(ctx, "+56987654321")
+	require.NoError(t, err)
+	assert.Equal(t, 2, agg.TotalReports)
+
+	withoutSpool := service.NewReportService(&downForWrites{MockRepo: NewMockRepo(), down: true}, mustKeyring(t, "", "secret_salt"))
+	assert.Error(t, withoutSpool.IngestReport(ctx, "+56987654321", "user_A", "FRAUD", "", ""))
+}
+
+func TestModerationItemsCarryTheIngestTrace(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithRemoteSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID, SpanID: spanID, TraceFlags: trace.FlagsSampled, Remote: true,
+	}))
+	const threat = "Me dijo que sabe donde vivo y que te voy a matar"
+
+	repo := &downForWrites{MockRepo: NewMockRepo()}
+	spooled := &memorySpool{}
+	svc := service.NewReportService(repo, mustKeyring(t, "", "secret_salt"), service.WithSpool(spooled, nil))
+	require.NoError(t, svc.IngestReport(ctx, "+56987654321", "user_A", "FRAUD", threat, "es"))
+
+	repo.down = true
+	require.NoError(t, svc.IngestReport(ctx, "+56987654321", "user_B", "FRAUD", threat, "es"))
+	repo.down = false
+	require.NoError(t, svc.ReplaySpooled(context.Background(), spooled.entries[0]))
+
+	require.Len(t, repo.moderation, 2)
+	for _, item := range repo.moderation {
+		assert.Contains(t, item.TraceContext["traceparent"], traceID.String(), "direct and replayed ingests alike")
+	}
+}
+
+// failingAggregates fails aggregate reads while down, as a store that
+// takes the report and then goes away would.
+type failingAggregates struct {
+	*MockRepo
+	down bool
+}
+
+func (r *failingAggregates) GetAggregate(ctx context.Context, phone string) (*domain.PhoneAggregate, error) {
+	if r.down {
+		return nil, errors.New("no hosts available")
+	}
+	return r.MockRepo.GetAggregate(ctx, phone)
+}
+
+func TestReplayIndexesReportsAnEarlierReplaySavedOnly(t *testing.T) {
+	ctx := context.Background()
+	repo := &failingAggregates{MockRepo: NewMockRepo()}
+	spooled := &memorySpool{}
+	svc := service.NewReportService(&downForWrites{MockRepo: NewMockRepo(), down: true}, mustKeyring(t, "", "secret_salt"), service.WithSpool(spooled, nil))
+	require.NoError(t, svc.IngestReport(ctx, "+56987654321", "user_A", "FRAUD",
+		"Me dijo que sabe donde vivo y que te voy a matar", "es"))
+	require.NoError(t, svc.IngestReport(ctx, "+56987654321", "user_B", "SPAM", "", ""))
+	require.Len(t, spooled.entries, 2)
+
+	svc = service.NewReportService(repo, mustKeyring(t, "", "secret_salt"))
+	require.NoError(t, svc.ReplaySpooled(ctx, spooled.entries[0]))
+
+	repo.down = true
+	assert.Error(t, svc.ReplaySpooled(ctx, spooled.entries[1]), "saved, but not indexed")
+	require.Len(t, repo.reports, 2)
+
+	repo.down = false
+	for range 2 {
+		for _, payload := range spooled.entries {
+			require.NoError(t, svc.ReplaySpooled(ctx, payload))
+		}
+	}
+
+	require.Len(t, repo.reports, 2)
+	require.Len(t, repo.moderation, 1, "the moderation item is enqueued once")
+	agg, err := repo.GetAggregate(ctx, "+56987654321")
+	require.NoError(t, err)
+	assert.Equal(t, 2, agg.TotalReports, "every report is counted once")
+	assert.Equal(t, 1.0, math.Round(agg.CategoryDecay[domain.RiskSpam]))
+}
+
+// reversingSealer stands in for envelope encryption: reversible, and never
+// equal to the plaintext.
+type reversingSealer struct{}
+
+func (reversingSealer) SealComment(ctx context.Context, r *domain.Report) (string, error) {
+	runes := []rune(r.Comment)
+	slices.Reverse(runes)
+	return "sealed:" + string(runes), nil
+}
+
+func (reversingSealer) OpenComment(ctx context.Context, r *domain.Report) (string, error) {
+	sealed, ok := strings.CutPrefix(r.Comment, "sealed:")
+	if !ok {
+		return r.Comment, nil
+	}
+	runes := []rune(sealed)
+	slices.Reverse(runes)
+	return string(runes), nil
+}
+
+func TestSpoolNeverHoldsPlaintextComments(t *testing.T) {
+	ctx := context.Background()
+	repo := &downForWrites{MockRepo: NewMockRepo(), down: true}
+	spooled := &memorySpool{}
+	svc := service.NewReportService(repo, mustKeyring(t, "", "secret_salt"), service.WithSpool(spooled, reversingSealer{}))
+
+	const comment = "llaman del banco pidiendo la clave"
+	require.NoError(t, svc.IngestReport(ctx, "+56987654321", "user_A", "FRAUD", comment, "es"))
+	require.Len(t, spooled.entries, 1)
+	assert.NotContains(t, string(spooled.entries[0]), comment)
+	assert.NotContains(t, string(spooled.entries[0]), "clave")
+
+	repo.down = false
+	require.NoError(t, svc.ReplaySpooled(ctx, spooled.entries[0]))
+	require.Len(t, repo.reports, 1)
+	assert.Equal(t, comment, repo.reports[0].Comment, "the repository gets the comment to encrypt it as usual")
+}
+
+type recordingObserver struct {
+	ingested          map[string]int
+	transitions       []string
+	aggregateFailures int
+}
+
+func (o *recordingObserver) ReportIngested(category domain.RiskCategory, country string) {
+	o.ingested[string(category)+"/"+country]++
+}
+
+func (o *recordingObserver) ReportSpooled(country string) {}
+
+func (o *recordingObserver) AggregateUpdateFailed(country string) {
+	o.aggregateFailures++
+}
+
+func (o *recordingObserver) RiskRecalculated(elapsed time.Duration, from, to domain.RiskLevel) {
+	o.transitions = append(o.transitions, string(from)+"->"+string(to))
+}
+
+// contendedAggregates loses every compare-and-swap while contended, as if
+// other writers always got there first.
+type contendedAggregates struct {
+	*MockRepo
+	contended bool
+}
+
+func (r *contendedAggregates) CompareAndSwapAggregate(ctx context.Context, agg *domain.PhoneAggregate, ttl int) (bool, error) {
+	if r.contended {
+		return false, nil
+	}
+	return r.MockRepo.CompareAndSwapAggregate(ctx, agg, ttl)
+}
+
+func TestIngestAcceptsStoredReportsWhenTheAggregateIsContended(t *testing.T) {
+	ctx := context.Background()
+	repo := &contendedAggregates{MockRepo: NewMockRepo()}
+	observer := &recordingObserver{ingested: map[string]int{}}
+	svc := service.NewReportService(repo, mustKeyring(t, "", "secret_salt"), service.WithObserver(observer))
+
+	require.NoError(t, svc.IngestReport(ctx, "+56987654321", "user_A", "FRAUD", "", ""))
+
+	repo.contended = true
+	require.NoError(t, svc.IngestReport(ctx, "+56987654321", "user_B", "FRAUD", "", ""),
+		"the report is stored, so a retry would only duplicate it")
+	require.Len(t, repo.reports, 2)
+	assert.Equal(t, 1, observer.aggregateFailures)
+	assert.Equal(t, map[string]int{"FRAUD/CL": 2}, observer.ingested)
+
+	agg, err := repo.GetAggregate(ctx, "+56987654321")
+	require.NoError(t, err)
+	assert.Equal(t, 1, agg.TotalReports)
+
+	repo.contended = false
+	require.NoError(t, svc.RebuildAggregate(ctx, "+56987654321"))
+	agg, err = repo.GetAggregate(ctx, "+56987654321")
+	require.NoError(t, err)
+	assert.Equal(t, 2, agg.TotalReports, "a rebuild repairs the aggregate")
+}
+
+func TestObserverSeesIngestAndLevelTransitions(t *testing.T) {
+	ctx := context.Background()
+	observer := &recordingObserver{ingested: map[string]int{}}
+	svc := service.NewReportService(NewMockRepo(), mustKeyring(t, "", "secret_salt"), service.WithObserver(observer))
+
+	for _, reporter := range []string{"user_A", "user_B", "user_C"} {
+		require.NoError(t, svc.IngestReport(ctx, "+56987654321", reporter, "fraud", "", ""))
+	}
+	require.NoError(t, svc.CalculateAndSaveRisk(ctx, "+56987654321"))
+	require.NoError(t, svc.CalculateAndSaveRisk(ctx, "+56987654321"))
+
+	assert.Equal(t, map[string]int{"FRAUD/CL": 3}, observer.ingested)
+	assert.Equal(t, []string{"SAFE->CRITICAL", "CRITICAL->CRITICAL"}, observer.transitions)
+}
+
+func TestThreatRegionsCoverNumbersWithoutASupportedRegion(t *testing.T) {
+	regions := service.ThreatRegions()
+	assert.True(t, sort.StringsAreSorted(regions))
+	for _, region := range []string{"CL", "US", service.UnknownRegion, "001"} {
+		assert.Contains(t, regions, region)
+	}
+}
+
+func mustKeyring(t *testing.T, spec, salt string) *service.Keyring {
+	keys, err := service.ParseKeyring(spec, salt)
+	require.NoError(t, err)
+	return keys
+}
+
+func (m *MockRepo) EnqueueModeration(ctx context.Context, item *domain.ModerationItem) error {
+	for i, existing := range m.moderation {
+		if existing.ReportID == item.ReportID && existing.EnqueuedAt.Equal(item.EnqueuedAt) {
+			m.moderation[i] = item
+			return nil
+		}
+	}
+	m.moderation = append(m.moderation, item)
+	return nil
+}
+
+func (m *MockRepo) ListModerationQueue(ctx context.Context, country string, limit int) ([]*domain.ModerationItem, error) {
+	return m.moderation, nil
 }
 
 func TestQuantumRiskAlgorithm(t *testing.T) {
@@ -164,7 +553,7 @@ func TestQuantumRiskAlgorithm(t *testing.T) {
 	for _, tc := range cases {
 		t.Run(tc.Name, func(t *testing.T) {
 			repo := NewMockRepo()
-			svc := service.NewReportService(repo, "secret_salt")
+			svc := service.NewReportService(repo, mustKeyring(t, "", "secret_salt"))
 
 			for _, action := range tc.Actions {
 				report := domain.NewReport(
@@ -181,14 +570,15 @@ func TestQuantumRiskAlgorithm(t *testing.T) {
 			err := svc.CalculateAndSaveRisk(context.Background(), tc.TargetPhone)
 			require.NoError(t, err)
 
-			savedScore, _ := repo.GetScore(context.Background(), tc.TargetPhone)
+			savedScore, err := repo.GetScore(context.Background(), tc.TargetPhone)
+			require.NoError(t, err)
 
 			if !tc.ShouldExist {
-				if savedScore != nil {
-					assert.Fail(t, "El score debería haber sido borrado (< 5.0) y sigue existiendo")
-				}
+				assert.NotContains(t, repo.scores, tc.TargetPhone, "El score debería haber sido borrado (< 5.0) y sigue existiendo")
+				assert.Equal(t, domain.LevelSafe, savedScore.RiskLevel, "Un número borrado vuelve a ser SAFE")
+				assert.Zero(t, savedScore.Score)
 			} else {
-				require.NotNil(t, savedScore, "El score debería existir en la DB")
+				require.Contains(t, repo.scores, tc.TargetPhone, "El score debería existir en la DB")
 				fmt.Printf("   -> %s Score Calculado: %.2f (Nivel: %s)\n", tc.Name, savedScore.Score, savedScore.RiskLevel)
 
 				assert.GreaterOrEqual(t, savedScore.Score, tc.ExpectedMin, "Score muy bajo")
@@ -198,3 +588,205 @@ func TestQuantumRiskAlgorithm(t *testing.T) {
 		})
 	}
 }
+
+func TestRecalculationReadsAggregateNotHistory(t *testing.T) {
+	ctx := context.Background()
+	phone := "+56987654321"
+
+	repo := NewMockRepo()
+	svc := service.NewReportService(repo, mustKeyring(t, "", "secret_salt"))
+
+	for _, reporter := range []string{"user_A", "user_B", "user_A"} {
+		require.NoError(t, svc.IngestReport(ctx, phone, reporter, "FRAUD", "", "es"))
+	}
+
+	repo.streams = 0
+	require.NoError(t, svc.CalculateAndSaveRisk(ctx, phone))
+	assert.Zero(t, repo.streams, "scoring must not reread the partition")
+
+	score, err := repo.GetScore(ctx, phone)
+	require.NoError(t, err)
+	require.NotNil(t, score)
+	assert.InDelta(t, 60.0, score.Score, 0.1, "three FRAUD reports from two reporters")
+	assert.Equal(t, 3, score.TotalReports)
+}
+
+func TestLegacyHistoryIsBackfilledOnce(t *testing.T) {
+	ctx := context.Background()
+	phone := "+56987654321"
+
+	repo := NewMockRepo()
+	svc := service.NewReportService(repo, mustKeyring(t, "", "secret_salt"))
+
+	for i := 0; i < 8; i++ {
+		report := domain.NewReport(phone, "CL", fmt.Sprintf("recent_%d", i), domain.RiskFraud, "")
+		report.CreatedAt = time.Now().UTC().Add(-time.Duration(8*24+i) * time.Hour)
+		require.NoError(t, repo.SaveRawReport(ctx, report))
+	}
+	for i := 0; i < 3; i++ {
+		report := domain.NewReport(phone, "CL", "sys", domain.RiskAutoBlock, "")
+		report.CreatedAt = time.Now().UTC().Add(-time.Duration(i) * time.Hour)
+		require.NoError(t, repo.SaveRawReport(ctx, report))
+	}
+
+	require.NoError(t, svc.CalculateAndSaveRisk(ctx, phone))
+	assert.Equal(t, 1, repo.streams)
+
+	score, err := repo.GetScore(ctx, phone)
+	require.NoError(t, err)
+	require.NotNil(t, score)
+	assert.Equal(t, 100.0, score.Score)
+	assert.Equal(t, 11, score.TotalReports)
+	assert.Equal(t, 3, score.VelocityHitCount)
+
+	require.NoError(t, svc.CalculateAndSaveRisk(ctx, phone))
+	assert.Equal(t, 1, repo.streams, "the backfilled aggregate is reused")
+}
+
+func TestExplainMatchesStoredScoreAndWritesNothing(t *testing.T) {
+	ctx := context.Background()
+	phone := "+56987654321"
+
+	repo := NewMockRepo()
+	svc := service.NewReportService(repo, mustKeyring(t, "", "secret_salt"))
+
+	for i := 0; i < 3; i++ {
+		report := domain.NewReport(phone, "CL", fmt.Sprintf("legacy_%d", i), domain.RiskFraud, "")
+		report.CreatedAt = time.Now().UTC().Add(-time.Duration(i+1) * time.Hour)
+		require.NoError(t, repo.SaveRawReport(ctx, report))
+	}
+	report := domain.NewReport(phone, "CL", "legacy_0", domain.RiskSpam, "")
+	require.NoError(t, repo.SaveRawReport(ctx, report))
+
+	x, err := svc.ExplainRisk(ctx, phone)
+	require.NoError(t, err)
+	assert.Empty(t, repo.aggregates, "explaining a legacy number must not backfill it")
+	assert.Empty(t, repo.scores)
+
+	require.Len(t, x.Categories, 2)
+	assert.Equal(t, domain.RiskFraud, x.Categories[0].Category, "largest contribution first")
+	assert.Equal(t, 3, x.Reporters)
+	assert.Equal(t, 0.30, x.ConsensusFactor)
+	assert.InDelta(t, x.RawScore*x.ConsensusFactor, x.Score, 0.01)
+
+	require.NoError(t, svc.CalculateAndSaveRisk(ctx, phone))
+	score, err := repo.GetScore(ctx, phone)
+	require.NoError(t, err)
+	require.NotNil(t, score)
+	assert.Equal(t, score.Score, x.Score)
+	assert.Equal(t, score.RiskLevel, x.RiskLevel)
+	assert.Equal(t, 4, x.TotalReports)
+}
+
+func TestHashPrefixLookup(t *testing.T) {
+	repo := NewMockRepo()
+	svc := service.NewReportService(repo, mustKeyring(t, "", "secret_salt"))
+	ctx := context.Background()
+
+	for _, reporter := range []string{"user_A", "user_B", "user_C"} {
+		report := domain.NewReport("+56922222222", "CL", reporter, domain.RiskFraud, "")
+		require.NoError(t, repo.SaveRawReport(ctx, report))
+	}
+	require.NoError(t, svc.CalculateAndSaveRisk(ctx, "+56922222222"))
+
+	hash := domain.HashPhone("+56922222222")
+
+	matches, err := svc.CheckRiskByHashPrefix(ctx, strings.ToUpper(hash[:5]))
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, hash, matches[0].PhoneHash)
+	assert.Equal(t, domain.LevelCritical, matches[0].RiskLevel)
+
+	for _, bad := range []string{hash[:3], hash[:9], "zzzz"} {
+		_, err := svc.CheckRiskByHashPrefix(ctx, bad)
+		assert.ErrorIs(t, err, service.ErrInvalidHashPrefix, bad)
+	}
+}
+
+type unreachableRepo struct {
+	*MockRepo
+}
+
+func (unreachableRepo) GetScore(ctx context.Context, phone string) (*domain.PhoneScore, error) {
+	return nil, errors.New("no hosts available")
+}
+
+type fixedSnapshot struct {
+	scores  map[string]*domain.PhoneScore
+	takenAt time.Time
+}
+
+func (f fixedSnapshot) Lookup(phone string) (*domain.PhoneScore, time.Time, bool) {
+	if f.scores == nil {
+		return nil, time.Time{}, false
+	}
+	score, ok := f.scores[phone]
+	if !ok {
+		return &domain.PhoneScore{PhoneNumber: phone, RiskLevel: domain.LevelSafe}, f.takenAt, true
+	}
+	copied := *score
+	return &copied, f.takenAt, true
+}
+
+func TestCheckRiskFailsOpenToSnapshot(t *testing.T) {
+	ctx := context.Background()
+	takenAt := time.Date(2025, 6, 1, 3, 0, 0, 0, time.UTC)
+	repo := unreachableRepo{NewMockRepo()}
+	snap := fixedSnapshot{
+		scores: map[string]*domain.PhoneScore{
+			"+56911111111": {PhoneNumber: "+56911111111", Score: 80, RiskLevel: domain.LevelCritical},
+		},
+		takenAt: takenAt,
+	}
+	svc := service.NewReportService(repo, mustKeyring(t, "", "secret_salt"), service.WithSnapshot(snap))
+
+	score, err := svc.CheckRisk(ctx, "+56911111111")
+	require.NoError(t, err)
+	assert.Equal(t, domain.LevelCritical, score.RiskLevel)
+	assert.True(t, score.Stale)
+	assert.Equal(t, takenAt, score.SnapshotAt)
+
+	score, err = svc.CheckRisk(ctx, "+56922222222")
+	require.NoError(t, err)
+	assert.Equal(t, domain.LevelSafe, score.RiskLevel)
+	assert.True(t, score.Stale)
+
+	noSnapshot := service.NewReportService(repo, mustKeyring(t, "", "secret_salt"), service.WithSnapshot(fixedSnapshot{}))
+	_, err = noSnapshot.CheckRisk(ctx, "+56911111111")
+	assert.Error(t, err, "without a loaded snapshot the failure surfaces")
+}
+
+func TestReporterKeyRotation(t *testing.T) {
+	repo := NewMockRepo()
+	ctx := context.Background()
+	phone := "+56987654321"
+
+	before := service.NewReportService(repo, mustKeyring(t, "", "leaked_salt"))
+	require.NoError(t, before.IngestReport(ctx, phone, "user_A", "FRAUD", "", ""))
+	require.NoError(t, before.IngestReport(ctx, phone, "user_B", "FRAUD", "", ""))
+
+	during := service.NewReportService(repo, mustKeyring(t, "2:fresh_salt,1:leaked_salt", ""))
+	require.NoError(t, during.IngestReport(ctx, phone, "user_A", "FRAUD", "", ""))
+
+	require.NoError(t, during.CalculateAndSaveRisk(ctx, phone))
+	score, _ := repo.GetScore(ctx, phone)
+	require.NotNil(t, score)
+	assert.InDelta(t, 60.0, score.Score, 0.5, "user_A must count once across both keys")
+
+	updated, err := during.RehashReporters(ctx, phone)
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated)
+
+	for _, r := range repo.reports {
+		assert.Empty(t, r.ReporterPrevHash)
+	}
+
+	after := service.NewReportService(repo, mustKeyring(t, "2:fresh_salt", ""))
+	require.NoError(t, after.CalculateAndSaveRisk(ctx, phone))
+	score, _ = repo.GetScore(ctx, phone)
+	require.NotNil(t, score)
+	assert.InDelta(t, 60.0, score.Score, 0.5, "rehashed reports must keep linking user_A")
+
+	_, err = mustKeyring(t, "2:fresh_salt", "").Hash(1, "user_A")
+	assert.ErrorContains(t, err, "no secret for version 1", "a retired key cannot hash")
+}
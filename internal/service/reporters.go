@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+)
+
+func reporterIdentity(version int, hash string) string {
+	if version == 0 {
+		version = LegacyKeyVersion
+	}
+	return fmt.Sprintf("%d:%s", version, hash)
+}
+
+// RehashReporters moves the reports of a number onto the active key wherever
+// a rotation-window report links the old hash to the new one, then drops the
+// old-key hashes. Reporters that never reported again after the rotation
+// cannot be rehashed and keep their old hash.
+func (s *reportService) RehashReporters(ctx context.Context, phoneNumber string) (int, error) {
+	activeVersion := s.keys.ActiveVersion()
+
+	// First pass: collect the old-to-new hash links. Only these are kept in
+	// memory; the reports are streamed again for the updates.
+	links := make(map[string]string)
+	for r, err := range s.repo.StreamRawReports(ctx, phoneNumber, ReportRange{}) {
+		if err != nil {
+			return 0, err
+		}
+		if r.ReporterKeyVersion == activeVersion && r.ReporterPrevHash != "" {
+			links[reporterIdentity(r.ReporterPrevKeyVersion, r.ReporterPrevHash)] = r.ReporterHash
+		}
+	}
+
+	updated := 0
+	for r, err := range s.repo.StreamRawReports(ctx, phoneNumber, ReportRange{}) {
+		if err != nil {
+			return updated, err
+		}
+		if r.Category == domain.RiskAutoBlock {
+			continue
+		}
+
+		switch {
+		case r.ReporterKeyVersion == activeVersion && r.ReporterPrevHash == "":
+			continue
+		case r.ReporterKeyVersion == activeVersion:
+			r.ReporterPrevHash = ""
+			r.ReporterPrevKeyVersion = 0
+		default:
+			newHash, ok := links[reporterIdentity(r.ReporterKeyVersion, r.ReporterHash)]
+			if !ok {
+				continue
+			}
+			r.ReporterHash = newHash
+			r.ReporterKeyVersion = activeVersion
+			r.ReporterPrevHash = ""
+			r.ReporterPrevKeyVersion = 0
+		}
+
+		if err := s.repo.UpdateReporterHash(ctx, r); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+
+	// The reporter sketch still holds the old identities.
+	if updated > 0 {
+		if _, err := s.rebuildAggregate(ctx, phoneNumber); err != nil {
+			return updated, err
+		}
+	}
+
+	return updated, nil
+}
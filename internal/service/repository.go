@@ -2,20 +2,66 @@ package service
 
 import (
 	"context"
+	"iter"
+	"time"
 
 	"github.com/rgdevment/spam-registry/internal/domain"
 )
 
+// ReportRange bounds a raw report read on created_at. Since is inclusive,
+// Until exclusive, and a zero time leaves that end open. PageSize is the
+// number of rows fetched per round trip; zero uses the backend default.
+type ReportRange struct {
+	Since    time.Time
+	Until    time.Time
+	PageSize int
+}
+
+// Contains reports whether t falls inside the range.
+func (rng ReportRange) Contains(t time.Time) bool {
+	if !rng.Since.IsZero() && t.Before(rng.Since) {
+		return false
+	}
+	if !rng.Until.IsZero() && !t.Before(rng.Until) {
+		return false
+	}
+	return true
+}
+
 type Repository interface {
 	SaveRawReport(ctx context.Context, r *domain.Report) error
 
 	GetRawReports(ctx context.Context, phoneNumber string) ([]*domain.Report, error)
 
+	// StreamRawReports yields the reports of a number inside rng, newest
+	// first, fetching one page at a time. Stopping the range loop stops the
+	// read. A failed read yields a single non-nil error and ends the stream.
+	StreamRawReports(ctx context.Context, phoneNumber string, rng ReportRange) iter.Seq2[*domain.Report, error]
+
+	UpdateReporterHash(ctx context.Context, r *domain.Report) error
+
 	UpsertScore(ctx context.Context, s *domain.PhoneScore, ttlSeconds int) error
 
 	UpsertCountryThreat(ctx context.Context, s *domain.PhoneScore, ttlSeconds int) error
 
+	ListCountryThreats(ctx context.Context, countryCode string) ([]*domain.PhoneScore, error)
+
 	DeleteScore(ctx context.Context, phoneNumber string, countryCode string) error
 
 	GetScore(ctx context.Context, phoneNumber string) (*domain.PhoneScore, error)
+
+	GetScoresByHashPrefix(ctx context.Context, prefix string) ([]*domain.HashedScore, error)
+
+	EnqueueModeration(ctx context.Context, item *domain.ModerationItem) error
+
+	ListModerationQueue(ctx context.Context, countryCode string, limit int) ([]*domain.ModerationItem, error)
+
+	// GetAggregate returns the stored aggregate of a number, or an empty one
+	// with Version 0 when there is none.
+	GetAggregate(ctx context.Context, phoneNumber string) (*domain.PhoneAggregate, error)
+
+	// CompareAndSwapAggregate stores agg only if the stored version still
+	// equals agg.Version (0: nothing stored). On success agg.Version is
+	// incremented; a lost race returns false and no error.
+	CompareAndSwapAggregate(ctx context.Context, agg *domain.PhoneAggregate, ttlSeconds int) (bool, error)
 }
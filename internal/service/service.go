@@ -7,9 +7,21 @@ import (
 )
 
 type Service interface {
-	IngestReport(ctx context.Context, rawPhone, rawReporter, category, comment string) error
+	IngestReport(ctx context.Context, rawPhone, rawReporter, category, comment, lang string) error
 
 	CheckRisk(ctx context.Context, phoneNumber string) (*domain.PhoneScore, error)
+	CheckRiskByHashPrefix(ctx context.Context, prefix string) ([]*domain.HashedScore, error)
+
+	ListPublicReports(ctx context.Context, phoneNumber, lang string, page, limit int) (*domain.PublicReportPage, error)
 
 	CalculateAndSaveRisk(ctx context.Context, phoneNumber string) error
+	ExplainRisk(ctx context.Context, phoneNumber string) (*domain.ScoreExplanation, error)
+
+	RehashReporters(ctx context.Context, phoneNumber string) (int, error)
+
+	// RebuildAggregate recomputes the aggregate of a number from its
+	// reports, repairing one an ingest could not update.
+	RebuildAggregate(ctx context.Context, phoneNumber string) error
+
+	ReplaySpooled(ctx context.Context, payload []byte) error
 }
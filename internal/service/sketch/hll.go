@@ -0,0 +1,152 @@
+// Package sketch holds the probabilistic counters kept in phone aggregates.
+package sketch
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"slices"
+)
+
+// Dense sketches use 2^10 one-byte registers: 1 KiB per number and ~3%
+// standard error. Below sparseLimit distinct items the exact 64-bit hashes
+// are kept instead, so the small counts scoring cares about are exact.
+const (
+	precision   = 10
+	registers   = 1 << precision
+	sparseLimit = registers / 8
+
+	tagSparse byte = 1
+	tagDense  byte = 2
+)
+
+var ErrInvalidSketch = errors.New("sketch: invalid HyperLogLog encoding")
+
+type HyperLogLog struct {
+	sparse []uint64 // sorted; nil once dense
+	dense  *[registers]uint8
+}
+
+func NewHyperLogLog() *HyperLogLog {
+	return &HyperLogLog{}
+}
+
+// FromBytes decodes Bytes. An empty slice is an empty sketch.
+func FromBytes(b []byte) (*HyperLogLog, error) {
+	h := NewHyperLogLog()
+	if len(b) == 0 {
+		return h, nil
+	}
+
+	switch body := b[1:]; b[0] {
+	case tagSparse:
+		if len(body)%8 != 0 || len(body)/8 > sparseLimit {
+			return nil, ErrInvalidSketch
+		}
+		for i := 0; i < len(body); i += 8 {
+			h.sparse = append(h.sparse, binary.BigEndian.Uint64(body[i:]))
+		}
+		if !slices.IsSorted(h.sparse) {
+			return nil, ErrInvalidSketch
+		}
+	case tagDense:
+		if len(body) != registers {
+			return nil, ErrInvalidSketch
+		}
+		h.dense = new([registers]uint8)
+		copy(h.dense[:], body)
+	default:
+		return nil, ErrInvalidSketch
+	}
+
+	return h, nil
+}
+
+func (h *HyperLogLog) Bytes() []byte {
+	if h.dense != nil {
+		return append([]byte{tagDense}, h.dense[:]...)
+	}
+
+	out := make([]byte, 1, 1+8*len(h.sparse))
+	out[0] = tagSparse
+	for _, x := range h.sparse {
+		out = binary.BigEndian.AppendUint64(out, x)
+	}
+	return out
+}
+
+func (h *HyperLogLog) Add(item string) {
+	x := hash64(item)
+
+	if h.dense != nil {
+		h.addDense(x)
+		return
+	}
+
+	i, found := slices.BinarySearch(h.sparse, x)
+	if found {
+		return
+	}
+	h.sparse = slices.Insert(h.sparse, i, x)
+
+	if len(h.sparse) > sparseLimit {
+		h.dense = new([registers]uint8)
+		for _, x := range h.sparse {
+			h.addDense(x)
+		}
+		h.sparse = nil
+	}
+}
+
+func (h *HyperLogLog) addDense(x uint64) {
+	idx := x >> (64 - precision)
+	rank := uint8(bits.LeadingZeros64(x<<precision|1<<(precision-1))) + 1
+
+	if rank > h.dense[idx] {
+		h.dense[idx] = rank
+	}
+}
+
+// Estimate returns the number of distinct items added: exact while sparse,
+// approximate once dense.
+func (h *HyperLogLog) Estimate() int {
+	if h.dense == nil {
+		return len(h.sparse)
+	}
+
+	var sum float64
+	zeros := 0
+	for _, r := range h.dense {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	m := float64(registers)
+	estimate := 0.7213 / (1 + 1.079/m) * m * m / sum
+
+	// Linear counting is more accurate for small cardinalities.
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+
+	return int(math.Round(estimate))
+}
+
+// hash64 is FNV-1a followed by the splitmix64 finalizer, which spreads the
+// bits FNV leaves correlated. It must stay stable: sketches are persisted.
+func hash64(s string) uint64 {
+	f := fnv.New64a()
+	f.Write([]byte(s))
+	x := f.Sum64()
+
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
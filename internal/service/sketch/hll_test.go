@@ -0,0 +1,56 @@
+package sketch_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rgdevment/spam-registry/internal/service/sketch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHyperLogLogSmallCountsAreExact(t *testing.T) {
+	h := sketch.NewHyperLogLog()
+	assert.Equal(t, 0, h.Estimate())
+
+	for i := 1; i <= 128; i++ {
+		h.Add(fmt.Sprintf("1:reporter_%d", i))
+		h.Add(fmt.Sprintf("1:reporter_%d", i))
+		assert.Equal(t, i, h.Estimate())
+	}
+}
+
+func TestHyperLogLogLargeCountsWithinError(t *testing.T) {
+	h := sketch.NewHyperLogLog()
+	for i := 0; i < 100000; i++ {
+		h.Add(fmt.Sprintf("1:reporter_%d", i))
+	}
+
+	assert.InDelta(t, 100000, h.Estimate(), 100000*0.1)
+}
+
+func TestHyperLogLogRoundTrip(t *testing.T) {
+	h := sketch.NewHyperLogLog()
+	for i := 0; i < 50; i++ {
+		h.Add(fmt.Sprintf("r%d", i))
+	}
+
+	decoded, err := sketch.FromBytes(h.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, 50, decoded.Estimate(), "sparse")
+
+	for i := 50; i < 5000; i++ {
+		h.Add(fmt.Sprintf("r%d", i))
+	}
+	decoded, err = sketch.FromBytes(h.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, h.Estimate(), decoded.Estimate(), "dense")
+	assert.Len(t, h.Bytes(), 1025)
+
+	_, err = sketch.FromBytes([]byte{1, 2, 3})
+	assert.ErrorIs(t, err, sketch.ErrInvalidSketch)
+
+	empty, err := sketch.FromBytes(nil)
+	require.NoError(t, err)
+	assert.Zero(t, empty.Estimate())
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+)
+
+// ReportSpool durably holds reports until the repository takes them again.
+type ReportSpool interface {
+	Append(payload []byte) error
+}
+
+// CommentSealer encrypts a report's comment with the keys the repository
+// stores it under, for copies kept outside the repository such as the spool.
+// OpenComment returns values SealComment did not produce unchanged.
+type CommentSealer interface {
+	SealComment(ctx context.Context, report *domain.Report) (string, error)
+	OpenComment(ctx context.Context, report *domain.Report) (string, error)
+}
+
+type spooledReport struct {
+	Report *domain.Report          `json:"report"`
+	Flags  []domain.ModerationFlag `json:"flags,omitempty"`
+
+	// TraceContext carries the W3C traceparent of the ingest, so its replay
+	// joins the same trace.
+	TraceContext map[string]string `json:"trace_context,omitempty"`
+}
+
+// spoolReport keeps a report whose save failed with saveErr. The report is
+// accepted once it is on disk. Its comment is sealed first, so the spool
+// never holds what crypto-shredding would have to reach.
+func (s *reportService) spoolReport(ctx context.Context, report *domain.Report, flags []domain.ModerationFlag, saveErr error) error {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	if s.sealer != nil && report.Comment != "" {
+		sealed, err := s.sealer.SealComment(ctx, report)
+		if err != nil {
+			return fmt.Errorf("%w (spool: %v)", saveErr, err)
+		}
+		copied := *report
+		copied.Comment = sealed
+		report = &copied
+	}
+
+	payload, err := json.Marshal(spooledReport{Report: report, Flags: flags, TraceContext: carrier})
+	if err != nil {
+		return err
+	}
+	if err := s.spool.Append(payload); err != nil {
+		return fmt.Errorf("%w (spool: %v)", saveErr, err)
+	}
+	return nil
+}
+
+// ReplaySpooled stores a spooled report. A report already saved, by an
+// earlier replay that did not get to acknowledge it, is not saved again but
+// is indexed again: that replay may have stopped before indexing it.
+func (s *reportService) ReplaySpooled(ctx context.Context, payload []byte) (err error) {
+	var entry spooledReport
+	if err := json.Unmarshal(payload, &entry); err != nil || entry.Report == nil {
+		// Retrying cannot fix it; keeping it would block everything behind.
+		slog.WarnContext(ctx, "⚠️  Dropping unreadable spooled report", "error", err)
+		return nil
+	}
+	report := entry.Report
+
+	if s.sealer != nil {
+		if report.Comment, err = s.sealer.OpenComment(ctx, report); err != nil {
+			return err
+		}
+	}
+
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(entry.TraceContext))
+	ctx, span := otel.Tracer("github.com/rgdevment/spam-registry/internal/service").Start(ctx, "service.ReplaySpooled")
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	saved, err := s.reportSaved(ctx, report)
+	if err != nil {
+		return err
+	}
+	if !saved {
+		if err := s.repo.SaveRawReport(ctx, report); err != nil {
+			return err
+		}
+	}
+	return s.indexReport(ctx, report, entry.Flags, saved)
+}
+
+func (s *reportService) reportSaved(ctx context.Context, report *domain.Report) (bool, error) {
+	since := report.CreatedAt.Truncate(time.Millisecond)
+	rng := ReportRange{Since: since, Until: since.Add(time.Millisecond)}
+
+	for stored, err := range s.repo.StreamRawReports(ctx, report.PhoneNumber, rng) {
+		if err != nil {
+			return false, err
+		}
+		if stored.ID == report.ID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,282 @@
+// Package client is the Go client of the Global Spam Registry HTTP API.
+//
+// A Client is safe for concurrent use. Every call takes a context and sends
+// the API key. Lookups are retried on 429 and 5xx with exponential backoff;
+// reports only on 429 and 503, so a retry never files one twice:
+//
+//	c, err := client.New("https://gsr.example.com", apiKey)
+//	score, err := c.Lookup(ctx, "+56912345678")
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+)
+
+// The API's own types, so callers can name them.
+type (
+	PhoneScore       = domain.PhoneScore
+	HashedScore      = domain.HashedScore
+	PublicReport     = domain.PublicReport
+	PublicReportPage = domain.PublicReportPage
+	Category         = domain.RiskCategory
+	RiskLevel        = domain.RiskLevel
+)
+
+// Categories a report can be filed under.
+const (
+	CategorySpam     = domain.RiskSpam
+	CategoryFraud    = domain.RiskFraud
+	CategoryPhishing = domain.RiskPhishing
+	CategoryDebt     = domain.RiskDebt
+	CategorySales    = domain.RiskSales
+)
+
+// Risk levels of a score.
+const (
+	LevelSafe     = domain.LevelSafe
+	LevelWarning  = domain.LevelWarning
+	LevelCritical = domain.LevelCritical
+)
+
+const (
+	defaultTimeout     = 10 * time.Second
+	defaultRetries     = 3
+	defaultBaseBackoff = 100 * time.Millisecond
+	defaultMaxBackoff  = 5 * time.Second
+	defaultConcurrency = 8
+
+	// maxErrorBody caps how much of an error response is kept.
+	maxErrorBody = 1 << 10
+)
+
+type Client struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+
+	retries     int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+	concurrency int
+	userAgent   string
+}
+
+// New returns a client of the API at baseURL, e.g. "https://gsr.example.com".
+func New(baseURL, apiKey string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("client: invalid base URL %q", baseURL)
+	}
+
+	c := &Client{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		apiKey:      apiKey,
+		http:        &http.Client{Timeout: defaultTimeout},
+		retries:     defaultRetries,
+		baseBackoff: defaultBaseBackoff,
+		maxBackoff:  defaultMaxBackoff,
+		concurrency: defaultConcurrency,
+		userAgent:   "gsr-go-client",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Report is a report to file against a number.
+type Report struct {
+	PhoneNumber string   `json:"phone_number"` // E.164, e.g. +56912345678
+	Category    Category `json:"category"`
+	Comment     string   `json:"comment,omitempty"`
+	Lang        string   `json:"lang,omitempty"` // ISO 639-1
+
+	// ReporterID identifies the person reporting, so the registry can tell
+	// many reporters from one reporting many times. It is hashed on receipt.
+	ReporterID string `json:"-"`
+}
+
+// ReportNumber files a report. The API accepts it for scoring later; the
+// score of the number does not change on return.
+func (c *Client) ReportNumber(ctx context.Context, r Report) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{"Content-Type": {"application/json"}}
+	if r.ReporterID != "" {
+		header.Set("X-Reporter-ID", r.ReporterID)
+	}
+	return c.do(ctx, http.MethodPost, "/v1/reports", body, header, nil)
+}
+
+// Lookup returns the score of a number. A number nobody reported scores 0
+// and is SAFE.
+func (c *Client) Lookup(ctx context.Context, phoneNumber string) (*PhoneScore, error) {
+	var score PhoneScore
+	if err := c.do(ctx, http.MethodGet, "/v1/phone/"+url.PathEscape(phoneNumber), nil, nil, &score); err != nil {
+		return nil, err
+	}
+	return &score, nil
+}
+
+// LookupResult is the outcome of one number of a BatchLookup.
+type LookupResult struct {
+	PhoneNumber string
+	Score       *PhoneScore
+	Err         error
+}
+
+// BatchLookup looks up every number, a few at a time, and returns the
+// results in the order of phoneNumbers. The API has no batch endpoint: each
+// number is a Lookup, and one failing does not stop the others.
+func (c *Client) BatchLookup(ctx context.Context, phoneNumbers []string) []LookupResult {
+	results := make([]LookupResult, len(phoneNumbers))
+	sem := make(chan struct{}, c.concurrency)
+
+	var wg sync.WaitGroup
+	for i, phone := range phoneNumbers {
+		results[i].PhoneNumber = phone
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i].Score, results[i].Err = c.Lookup(ctx, phone)
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// StreamThreats yields the scored numbers whose SHA-256 hash starts with
+// each of prefixes, prefix by prefix. Only hashes leave the API: a caller
+// checks a number by hashing it locally with HashPhone. Iteration stops
+// after the first error.
+func (c *Client) StreamThreats(ctx context.Context, prefixes ...string) iter.Seq2[*HashedScore, error] {
+	return func(yield func(*HashedScore, error) bool) {
+		for _, prefix := range prefixes {
+			var page struct {
+				Matches []*HashedScore `json:"matches"`
+			}
+			if err := c.do(ctx, http.MethodGet, "/v1/range/"+url.PathEscape(prefix), nil, nil, &page); err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, m := range page.Matches {
+				if !yield(m, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// HashPhone is the hash StreamThreats matches: hex SHA-256 of the E.164
+// number.
+func HashPhone(e164 string) string {
+	return domain.HashPhone(e164)
+}
+
+// ListReports returns one page of the public, moderated reports of a
+// number, newest first. page starts at 1; lang, when set, prefers comments
+// in that language.
+func (c *Client) ListReports(ctx context.Context, phoneNumber, lang string, page, limit int) (*PublicReportPage, error) {
+	query := url.Values{}
+	if page > 0 {
+		query.Set("page", strconv.Itoa(page))
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if lang != "" {
+		query.Set("lang", lang)
+	}
+	path := "/v1/phone/" + url.PathEscape(phoneNumber) + "/reports"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var out PublicReportPage
+	if err := c.do(ctx, http.MethodGet, path, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// do sends one call, retrying it as retryable allows, and decodes a
+// successful response into out.
+func (c *Client) do(ctx context.Context, method, path string, body []byte, header http.Header, out any) error {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, method, path, body, header)
+		if err == nil && resp.StatusCode < http.StatusBadRequest {
+			defer resp.Body.Close()
+			if out == nil {
+				return nil
+			}
+			if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+				return fmt.Errorf("client: decoding %s %s: %w", method, path, err)
+			}
+			return nil
+		}
+
+		if err == nil {
+			err = newAPIError(resp)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if attempt >= c.retries || !retryable(method, err) {
+			return err
+		}
+
+		timer := time.NewTimer(c.backoff(attempt, err))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, path string, body []byte, header http.Header) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("X-API-Key", c.apiKey)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+
+	return c.http.Do(req)
+}
@@ -0,0 +1,165 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rgdevment/spam-registry/pkg/client"
+	"github.com/rgdevment/spam-registry/pkg/client/clienttest"
+)
+
+const phone = "+56987654321"
+
+func TestReportThenLookup(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	c := srv.Client()
+	ctx := context.Background()
+
+	for _, reporter := range []string{"user_A", "user_B", "user_C"} {
+		require.NoError(t, c.ReportNumber(ctx, client.Report{
+			PhoneNumber: phone,
+			Category:    client.CategoryFraud,
+			Comment:     "pide la clave del banco",
+			ReporterID:  reporter,
+		}))
+	}
+
+	reports := srv.Reports(phone)
+	require.Len(t, reports, 3)
+	assert.NotEqual(t, "user_A", reports[0].ReporterHash, "reporters are hashed on receipt")
+
+	require.NoError(t, srv.Recalculate(phone))
+	score, err := c.Lookup(ctx, phone)
+	require.NoError(t, err)
+	assert.Equal(t, client.LevelCritical, score.RiskLevel)
+	assert.Equal(t, "CL", score.CountryCode)
+
+	page, err := c.ListReports(ctx, phone, "", 1, 10)
+	require.NoError(t, err)
+	assert.Len(t, page.Reports, 3)
+}
+
+func TestRetriesTransientFailures(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	srv.SetScore(&client.PhoneScore{PhoneNumber: phone, CountryCode: "CL", Score: 42, RiskLevel: client.LevelWarning})
+
+	srv.FailNext(http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusInternalServerError)
+	score, err := srv.Client().Lookup(context.Background(), phone)
+	require.NoError(t, err)
+	assert.Equal(t, 42.0, score.Score)
+	assert.Equal(t, 4, srv.Requests())
+
+	srv.FailNext(http.StatusBadGateway, http.StatusBadGateway)
+	_, err = srv.Client(client.WithRetries(1, 0)).Lookup(context.Background(), phone)
+	var apiErr *client.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+}
+
+func TestReportIsNotRetriedWhenItMayHaveBeenStored(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	c := srv.Client()
+	report := client.Report{PhoneNumber: phone, Category: client.CategorySpam, ReporterID: "user_A"}
+
+	sent := 0
+	for _, status := range []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout} {
+		srv.FailNext(status)
+		err := c.ReportNumber(context.Background(), report)
+		var apiErr *client.APIError
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, status, apiErr.StatusCode)
+		sent++
+		assert.Equal(t, sent, srv.Requests(), "%d may come after the save", status)
+	}
+
+	srv.FailNext(http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	require.NoError(t, c.ReportNumber(context.Background(), report))
+	assert.Equal(t, sent+3, srv.Requests())
+	assert.Len(t, srv.Reports(phone), 1)
+}
+
+func TestClientErrorsAreNotRetried(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+
+	c, err := client.New(srv.URL, "wrong-key", client.WithRetries(3, 0))
+	require.NoError(t, err)
+
+	_, err = c.Lookup(context.Background(), phone)
+	var apiErr *client.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	assert.Equal(t, 1, srv.Requests())
+
+	err = srv.Client().ReportNumber(context.Background(), client.Report{PhoneNumber: phone, Category: "NOT_A_CATEGORY"})
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+}
+
+func TestBatchLookupKeepsOrderAndPerNumberErrors(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	srv.SetScore(&client.PhoneScore{PhoneNumber: phone, CountryCode: "CL", Score: 90, RiskLevel: client.LevelCritical})
+
+	results := srv.Client(client.WithConcurrency(2)).BatchLookup(context.Background(), []string{phone, "+1", "+56911111111"})
+	require.Len(t, results, 3)
+
+	assert.Equal(t, phone, results[0].PhoneNumber)
+	require.NoError(t, results[0].Err)
+	assert.Equal(t, client.LevelCritical, results[0].Score.RiskLevel)
+
+	assert.Error(t, results[1].Err, "too short for the API")
+
+	require.NoError(t, results[2].Err)
+	assert.Equal(t, client.LevelSafe, results[2].Score.RiskLevel)
+}
+
+func TestStreamThreatsYieldsHashesByPrefix(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	srv.SetScore(&client.PhoneScore{PhoneNumber: phone, CountryCode: "CL", Score: 90, RiskLevel: client.LevelCritical})
+	srv.SetScore(&client.PhoneScore{PhoneNumber: "+56911111111", CountryCode: "CL", Score: 30, RiskLevel: client.LevelWarning})
+
+	hash := client.HashPhone(phone)
+	var got []*client.HashedScore
+	for m, err := range srv.Client().StreamThreats(context.Background(), hash[:6]) {
+		require.NoError(t, err)
+		got = append(got, m)
+	}
+	require.Len(t, got, 1)
+	assert.Equal(t, hash, got[0].PhoneHash)
+
+	for _, err := range srv.Client().StreamThreats(context.Background(), "zz") {
+		assert.Error(t, err, "invalid prefixes stop the iteration")
+	}
+}
+
+type countingTransport struct {
+	calls int
+}
+
+func (t *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.calls++
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestPluggableTransport(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+
+	rt := &countingTransport{}
+	_, err := srv.Client(client.WithTransport(rt)).Lookup(context.Background(), phone)
+	require.NoError(t, err)
+	assert.Equal(t, 1, rt.calls)
+
+	_, err = client.New("not a url", "key")
+	assert.Error(t, err)
+}
@@ -0,0 +1,145 @@
+// Package clienttest runs a local API for tests of code built on
+// pkg/client. It serves the real handlers over an in-memory store, so
+// requests are validated and answered exactly as in production.
+//
+//	srv := clienttest.NewServer()
+//	defer srv.Close()
+//	srv.SetScore(&client.PhoneScore{PhoneNumber: "+56912345678", Score: 80, RiskLevel: client.LevelCritical})
+//	c := srv.Client()
+package clienttest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/rgdevment/spam-registry/internal/domain"
+	httpHandler "github.com/rgdevment/spam-registry/internal/platform/http"
+	"github.com/rgdevment/spam-registry/internal/platform/http/middleware"
+	"github.com/rgdevment/spam-registry/internal/platform/storage/memory"
+	"github.com/rgdevment/spam-registry/internal/service"
+	"github.com/rgdevment/spam-registry/pkg/client"
+)
+
+// APIKey is the key the server accepts.
+const APIKey = "clienttest-key"
+
+// scoreTTL keeps test scores alive for the whole test.
+const scoreTTL = 24 * 60 * 60
+
+type Server struct {
+	*httptest.Server
+
+	repo *memory.Repository
+	svc  service.Service
+
+	mu       sync.Mutex
+	failures []int
+	requests int
+}
+
+// NewServer starts a server. Close it when the test ends.
+func NewServer() *Server {
+	keys, err := service.NewKeyring(map[int]string{1: "clienttest-salt"})
+	if err != nil {
+		panic(err)
+	}
+
+	s := &Server{repo: memory.NewMemoryRepository()}
+	s.svc = service.NewReportService(s.repo, keys)
+
+	r := chi.NewRouter()
+	r.Use(s.count, s.inject, middleware.APIKeyAuth(APIKey))
+	httpHandler.NewHandler(s.svc).RegisterRoutes(r)
+
+	s.Server = httptest.NewServer(r)
+	return s
+}
+
+// Close shuts the server down and stops the store's sweeper.
+func (s *Server) Close() {
+	s.Server.Close()
+	s.repo.Close()
+}
+
+// Client returns a client of the server, authenticated with APIKey and
+// retrying without delay.
+func (s *Server) Client(opts ...client.Option) *client.Client {
+	opts = append([]client.Option{client.WithRetries(3, 0)}, opts...)
+	c, err := client.New(s.URL, APIKey, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// SetScore stores a score as if the worker had computed it. Range lookups
+// see it too.
+func (s *Server) SetScore(score *client.PhoneScore) {
+	ctx := context.Background()
+	if err := s.repo.UpsertScore(ctx, score, scoreTTL); err != nil {
+		panic(err)
+	}
+	if err := s.repo.UpsertCountryThreat(ctx, score, scoreTTL); err != nil {
+		panic(err)
+	}
+}
+
+// Recalculate scores a number from the reports it received, as the worker
+// would.
+func (s *Server) Recalculate(phoneNumber string) error {
+	return s.svc.CalculateAndSaveRisk(context.Background(), phoneNumber)
+}
+
+// Reports returns the reports stored for a number, newest first.
+func (s *Server) Reports(phoneNumber string) []*domain.Report {
+	reports, err := s.repo.GetRawReports(context.Background(), phoneNumber)
+	if err != nil {
+		panic(err)
+	}
+	return reports
+}
+
+// FailNext makes the next len(statuses) requests fail with those statuses,
+// in order, before they reach the API.
+func (s *Server) FailNext(statuses ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, statuses...)
+}
+
+// Requests counts the requests received, failed ones included.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func (s *Server) count(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests++
+		s.mu.Unlock()
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) inject(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		status := 0
+		if len(s.failures) > 0 {
+			status, s.failures = s.failures[0], s.failures[1:]
+		}
+		s.mu.Unlock()
+
+		if status != 0 {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
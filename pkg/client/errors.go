@@ -0,0 +1,73 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// APIError is a response the API answered with a 4xx or 5xx status.
+type APIError struct {
+	StatusCode int
+	Message    string
+
+	// RetryAfter is the wait the API asked for on 429 and 503, if any.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("gsr api: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+func newAPIError(resp *http.Response) *APIError {
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	e := &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(msg))}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+		e.RetryAfter = time.Duration(secs) * time.Second
+	}
+	return e
+}
+
+// retryable reports whether a failed call may be sent again. Lookups are
+// retried on any 429, 5xx or transport error. A report is only retried on
+// 429 and 503, which are answered before the save. Any other 5xx or a broken
+// connection may come after it, even a 502 or 504 from a proxy that gave up
+// waiting, and retrying would file the report twice.
+func retryable(method string, err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return method == http.MethodGet
+	}
+
+	switch apiErr.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	}
+	return method == http.MethodGet && apiErr.StatusCode >= http.StatusInternalServerError
+}
+
+// backoff is the wait before retry attempt+1: the API's Retry-After when it
+// sent one, otherwise an exponential delay with full jitter. Both are capped
+// at maxBackoff.
+func (c *Client) backoff(attempt int, err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return min(apiErr.RetryAfter, c.maxBackoff)
+	}
+
+	ceiling := c.baseBackoff << attempt
+	if ceiling <= 0 || ceiling > c.maxBackoff {
+		ceiling = c.maxBackoff
+	}
+	if c.baseBackoff <= 0 {
+		return 0
+	}
+	return rand.N(ceiling) + 1
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"net/http"
+	"time"
+)
+
+type Option func(*Client)
+
+// WithHTTPClient sends requests through hc instead of a default client with
+// a 10s timeout.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.http = hc
+	}
+}
+
+// WithTransport keeps the default client but sends its requests through rt,
+// e.g. to add tracing or a proxy.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		hc := *c.http
+		hc.Transport = rt
+		c.http = &hc
+	}
+}
+
+// WithRetries sets how many times a failed call is retried, 3 by default,
+// and the first backoff delay, which doubles on each retry up to 5s.
+// WithRetries(0, 0) disables retries.
+func WithRetries(retries int, baseBackoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.baseBackoff = baseBackoff
+	}
+}
+
+// WithConcurrency caps the lookups BatchLookup runs at once, 8 by default.
+func WithConcurrency(n int) Option {
+	return func(c *Client) {
+		if n > 0 {
+			c.concurrency = n
+		}
+	}
+}
+
+// WithUserAgent names the calling service in the User-Agent header.
+func WithUserAgent(ua string) Option {
+	return func(c *Client) {
+		c.userAgent = ua
+	}
+}